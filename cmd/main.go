@@ -2,11 +2,18 @@ package main
 
 import (
 	"context"
-	"fmt"
+	"errors"
+	"flag"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 	"goTSVParser/config"
 	"goTSVParser/internal/handler"
+	"goTSVParser/internal/lifecycle"
+	"goTSVParser/internal/logging"
+	"goTSVParser/internal/reload"
 	"goTSVParser/internal/service"
 	"goTSVParser/internal/storage"
+	"goTSVParser/internal/tracing"
 	"goTSVParser/internal/workers"
 	"log"
 	"os"
@@ -15,34 +22,78 @@ import (
 )
 
 func main() {
-	cnfg := config.New()
-	st, err := storage.NewDBStorage(cnfg)
+	cnfg, err := config.New()
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
 	if err != nil {
+		log.Printf("bad config: %v", err)
+		os.Exit(2)
+	}
+	if cnfg.PrintConfig {
+		if err := cnfg.Print(os.Stdout); err != nil {
+			log.Printf("failed to print config: %v", err)
+			os.Exit(1)
+		}
 		return
 	}
-	watcher := workers.NewWatcher(cnfg)
-	parser := workers.NewParser(cnfg)
+	logger, level, err := logging.New(cnfg.Log)
+	if err != nil {
+		log.Printf("failed to init logger: %v", err)
+		os.Exit(1)
+	}
+	zap.ReplaceGlobals(logger)
+	if !logger.Core().Enabled(zap.DebugLevel) {
+		gin.SetMode(gin.ReleaseMode)
+	}
+
+	shutdownTracing, err := tracing.Setup(context.Background(), cnfg.Tracing)
+	if err != nil {
+		logger.Fatal("failed to init tracing", zap.Error(err))
+	}
+	st, err := storage.NewDBStorage(cnfg)
+	if err != nil {
+		logger.Fatal("failed to init storage", zap.Error(err))
+	}
+	watcher := workers.NewWatcher(cnfg, logger.Named("watcher"))
+	parser, err := workers.NewParser(cnfg, logger.Named("parser"))
+	if err != nil {
+		logger.Fatal("failed to init parser", zap.Error(err))
+	}
 	writer := workers.NewWriter(cnfg)
-	s := service.NewService(st, watcher, parser, writer, cnfg)
-	h := handler.NewHandler(s, cnfg)
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-	go func() {
-		err := s.Worker(ctx)
-		fmt.Println(err)
-		if err != nil {
-			log.Fatal(err)
-		}
-	}()
-	go h.Start(ctx)
+	s := service.NewService(st, watcher, parser, writer, cnfg, logger.Named("worker"))
+	h := handler.NewHandler(s, cnfg, logger.Named("http"))
+
+	// refresh interval, directories, svg_gen, max_page_size, max_report_rows and log level are applied without restart
+	reloader := reload.New(cnfg, config.New, logger.Named("reload"))
+	reloader.OnReload(func(c config.Config) error {
+		return logging.SetLevel(level, c.Log.Level)
+	})
+	reloader.OnReload(func(c config.Config) error {
+		s.Reload(c)
+		return nil
+	})
 
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
-	<-quit
-	log.Println("stopping application")
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
+	defer stop()
 
-	cancel()
+	// components are stopped in reverse order: http drains requests, then worker finishes current file
+	app := lifecycle.New(cnfg.ShutdownTimeoutDuration(), logger)
+	app.Go("worker", s.Worker)
+	app.Go("http", h.Start)
+	app.Go("reload", reloader.Run)
+	// db is not closed under worker which is still writing
+	app.OnStop("storage", func(context.Context) error {
+		return st.ShutDown()
+	}, "worker")
+	app.OnStop("tracing", shutdownTracing)
 
-	st.ShutDown()
-	log.Println("shutting down application")
+	err = app.Run(ctx)
+	if err != nil {
+		logger.Error("application stopped with error", zap.Error(err))
+		logger.Sync()
+		os.Exit(1)
+	}
+	logger.Info("shutting down application")
+	logger.Sync()
 }
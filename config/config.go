@@ -1,106 +1,166 @@
 package config
 
 import (
-	"encoding/json"
-	"flag"
-	"io"
-	"os"
-	"strconv"
+	"time"
 )
 
 type Config struct {
-	Host            string `json:"host"`
-	TLS             bool   `json:"tls"`
-	Certificate     string `json:"certificate"`
-	PrivateKey      string `json:"private"`
-	DirectoryFrom   string `json:"dir_from"`
-	DirectoryTo     string `json:"dir_to"`
-	DB              string `json:"dsn"`
-	RefreshInterval int    `json:"refresh_interval"`
-	SvgGen          bool   `json:"svg_gen"`
-	CFile           string
+	Host            string   `json:"host"`
+	TLS             bool     `json:"tls"`
+	Certificate     string   `json:"certificate"`
+	PrivateKey      string   `json:"private" env:"PRIVATE_KEY"`
+	TLSDir          string   `json:"tls_dir"`
+	TLSHosts        []string `json:"tls_hosts"`
+	TLSMinVersion   string   `json:"tls_min_version"`
+	TLSCiphers      []string `json:"tls_ciphers"`
+	DirectoryFrom   string   `json:"dir_from" env:"DIRECTORY_FROM"`
+	DirectoryTo     string   `json:"dir_to" env:"DIRECTORY_TO"`
+	DB              string   `json:"dsn" env:"DATABASE_DSN"`
+	RefreshInterval int      `json:"refresh_interval"`
+	SvgGen          bool     `json:"svg_gen"`
+	Parser          Parser   `json:"parser"`
+	Auth            Auth     `json:"auth"`
+	Limits          Limits   `json:"limits"`
+	Tracing         Tracing  `json:"tracing"`
+	Log             Log      `json:"log"`
+	ShutdownTimeout int      `json:"shutdown_timeout"`
+	CFile           string   `json:"-"`
+	PrintConfig     bool     `json:"-"`
 }
 
-type F struct {
-	host            *string
-	tls             *bool
-	certificate     *string
-	privateKey      *string
-	directoryFrom   *string
-	directoryTo     *string
-	db              *string
-	refreshInterval *int
-	svgGen          *bool
-	cFile           *string
+const defaultShutdownTimeout = 30 * time.Second
+
+// ShutdownTimeoutDuration is time to drain requests and current file on stop
+func (c Config) ShutdownTimeoutDuration() time.Duration {
+	if c.ShutdownTimeout <= 0 {
+		return defaultShutdownTimeout
+	}
+	return time.Duration(c.ShutdownTimeout) * time.Second
 }
 
-var f F
+// Auth of http api, api is open when nothing is set
+type Auth struct {
+	APIKeys     []APIKey          `json:"api_keys"`
+	JWKS        string            `json:"jwks"`
+	Issuer      string            `json:"issuer"`
+	Audience    string            `json:"audience"`
+	RoleClaim   string            `json:"role_claim"`
+	ClientCA    string            `json:"client_ca"`
+	RequireCert bool              `json:"require_client_cert"`
+	ClientRoles map[string]string `json:"client_roles"`
+}
 
-const addr = "localhost:8080"
+// Parser of files
+type Parser struct {
+	// row is kept when it matches all rules, DefaultFilters when not set
+	Filters    []FilterRule `json:"filters"`
+	Validation Validation   `json:"validation"`
+	// layouts of files besides occurrence one, file is parsed by first matching schema
+	Schemas []Schema `json:"schemas"`
+	// input format by file extension, set ones are added to DefaultFormats
+	Formats map[string]string `json:"formats"`
+	// encoding of text files: auto, utf-8, cp1251, koi8-r, utf-16le or utf-16be, detected when empty
+	Encoding string `json:"encoding"`
+}
 
-func init() {
-	f.host = flag.String("a", addr, "-a=")
-	f.tls = flag.Bool("tls", false, "-tls=")
-	f.certificate = flag.String("cert", "", "-cert=path_to_certificate")
-	f.privateKey = flag.String("key", "", "-key=path_to_key")
-	f.directoryFrom = flag.String("f", "", "-f=from")
-	f.db = flag.String("d", "", "-d=db")
-	f.directoryTo = flag.String("t", "", "-t=to")
-	f.refreshInterval = flag.Int("r", 10, "interval of check")
-	f.svgGen = flag.Bool("svg", false, "-svg=")
-	f.cFile = flag.String("c", "", "config file")
+// DefaultFormats of input files, delimiter of csv is sniffed from first line
+var DefaultFormats = map[string]string{
+	".tsv":    "tsv",
+	".csv":    "csv",
+	".xlsx":   "xlsx",
+	".jsonl":  "jsonl",
+	".ndjson": "jsonl",
+}
 
+// Schema of file layout, rows are saved into own table and grouped into reports by key column
+type Schema struct {
+	Name     string       `json:"name"`
+	Match    string       `json:"match"` // glob of file name or of its last directories and name, e.g. alarms/*.tsv
+	Table    string       `json:"table"`
+	Key      string       `json:"key"` // column rows are grouped by, e.g. device_id
+	Columns  []Column     `json:"columns"`
+	Filters  []FilterRule `json:"filters"`
+	Encoding string       `json:"encoding"` // parser.encoding by default
 }
 
-func New() (c Config) {
-	flag.Parse()
-	if envHost := os.Getenv("HOST"); envHost != "" {
-		f.host = &envHost
-	}
-	if envCert := os.Getenv("CERTIFICATE"); envCert != "" {
-		f.certificate = &envCert
-	}
-	if envKey := os.Getenv("PRIVATE_KEY"); envKey != "" {
-		f.privateKey = &envKey
-	}
-	if envRunDirectoryFrom := os.Getenv("DIRECTORY_FROM"); envRunDirectoryFrom != "" {
-		f.directoryFrom = &envRunDirectoryFrom
-	}
-	if envRunDirectoryTo := os.Getenv("DIRECTORY_TO"); envRunDirectoryTo != "" {
-		f.directoryTo = &envRunDirectoryTo
-	}
-	if envDB := os.Getenv("DATABASE_DSN"); envDB != "" {
-		f.db = &envDB
-	}
-	envRefresh := os.Getenv("REFRESH_INTERVAL")
-	if refreshInterval, _ := strconv.Atoi(envRefresh); refreshInterval != 0 {
-		f.refreshInterval = &refreshInterval
-	}
-	c.Host = *f.host
-	c.TLS = *f.tls
-	c.Certificate = *f.certificate
-	c.PrivateKey = *f.privateKey
-	c.DirectoryFrom = *f.directoryFrom
-	c.DB = *f.db
-	c.DirectoryTo = *f.directoryTo
-	c.RefreshInterval = *f.refreshInterval
-	c.SvgGen = *f.svgGen
-	c.CFile = *f.cFile
-	file, err := os.Open(c.CFile)
-	if err != nil {
-		return
-	}
-	defer file.Close()
+// Column of schema in order of file columns
+type Column struct {
+	Name   string   `json:"name"`
+	Column string   `json:"column"` // db column, name by default
+	Type   string   `json:"type"`   // string, text, int, bool or guid, string by default
+	Min    *int     `json:"min"`
+	Max    *int     `json:"max"`
+	Values []string `json:"values"` // allowed values, any when empty
+}
 
-	all, err := io.ReadAll(file)
-	if err != nil {
-		return
+// DBColumn is name of db column of column
+func (c Column) DBColumn() string {
+	if c.Column != "" {
+		return c.Column
 	}
+	return c.Name
+}
 
-	err = json.Unmarshal(all, &c)
-	if err != nil {
-		return
-	}
-	return c
+// Validation of typed fields of rows, invalid rows are skipped and reported in job
+type Validation struct {
+	Number  Range    `json:"number"`
+	Level   Range    `json:"level"`
+	Bit     Range    `json:"bit"`
+	Classes []string `json:"classes"` // allowed message classes, any when empty
+	Areas   []string `json:"areas"`   // allowed areas, any when empty
+}
+
+// Range of integer field, bound is not checked when not set
+type Range struct {
+	Min *int `json:"min"`
+	Max *int `json:"max"`
+}
+
+// FilterRule match field of row by operator, e.g. unit_guid min_len 10
+type FilterRule struct {
+	Name  string `json:"name"`  // name in logs, metrics and jobs, "field op value" by default
+	Field string `json:"field"` // column name, e.g. unit_guid or level
+	Op    string `json:"op"`    // eq, ne, contains, prefix, suffix, regex, not_regex, min_len, max_len or required
+	Value string `json:"value"`
+}
+
+// DefaultFilters skip rows without unit guid, they can't be grouped into reports
+var DefaultFilters = []FilterRule{{Name: "unit_guid_too_short", Field: "unit_guid", Op: "min_len", Value: "10"}}
+
+// Limits of http api, zero value turns limit off
+type Limits struct {
+	RatePerSecond  float64 `json:"rate_per_second"`
+	Burst          int     `json:"burst"`
+	MaxBodyBytes   int64   `json:"max_body_bytes"`
+	MaxUploadBytes int64   `json:"max_upload_bytes"`
+	MaxPageSize    int     `json:"max_page_size"`
+	MaxReportRows  int     `json:"max_report_rows"`
+	RequestTimeout int     `json:"request_timeout"`
+	UploadTimeout  int     `json:"upload_timeout"`
+	// ips or cidrs of proxies whose X-Forwarded-For gives client ip, none by default
+	TrustedProxies []string `json:"trusted_proxies"`
+}
+
+// Log of all components, json to stderr on info level by default
+type Log struct {
+	Level  string `json:"level"`  // debug, info, warn or error
+	Format string `json:"format"` // json or console
+	Output string `json:"output"` // stdout, stderr or file path
+	// per second first messages with the same text are logged, then every thereafter one
+	SampleInitial    int `json:"sample_initial"`
+	SampleThereafter int `json:"sample_thereafter"`
+}
+
+// Tracing export, tracing is off when exporter is not set
+type Tracing struct {
+	Exporter    string  `json:"exporter"` // otlp or stdout
+	Endpoint    string  `json:"endpoint"` // host:port of otlp http collector
+	Insecure    bool    `json:"insecure"`
+	SampleRatio float64 `json:"sample_ratio"`
+}
 
+type APIKey struct {
+	Name string `json:"name"`
+	Key  string `json:"key"`
+	Role string `json:"role"`
 }
@@ -0,0 +1,144 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func env(vars map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		v, ok := vars[name]
+		return v, ok
+	}
+}
+
+func writeFile(t *testing.T, name, data string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	jsonFile := writeFile(t, "config.json", `{"dsn": "file", "dir_from": "`+dir+`", "dir_to": "`+dir+`", "refresh_interval": 5,
+		"limits": {"burst": 3}}`)
+	yamlFile := writeFile(t, "config.yaml", "dsn: file\ndir_from: "+dir+"\ndir_to: "+dir+"\nlimits:\n  burst: 4\ntls_hosts: [a, b]\n")
+	schemaFile := writeFile(t, "schema.yaml", "dsn: file\ndir_from: "+dir+"\ndir_to: "+dir+"\nparser:\n  schemas:\n"+
+		"  - {name: alarms, match: 'alarms/*.tsv', table: alarms, key: device, columns: [{name: device}, {name: code, type: int}]}\n")
+	badSchemaFile := writeFile(t, "bad_schema.yaml", "dsn: file\ndir_from: "+dir+"\ndir_to: "+dir+"\nparser:\n  schemas:\n"+
+		"  - {name: alarms, match: '*.tsv', table: jobs, key: device, columns: [{name: code, type: number}]}\n")
+	tomlFile := writeFile(t, "config.toml", "dsn = 'file'\ndir_from = '"+dir+"'\ndir_to = '"+dir+"'\n[auth]\nissuer = 'sso'\n")
+
+	tests := []struct {
+		name    string
+		args    []string
+		env     map[string]string
+		check   func(c Config) bool
+		wantErr string
+	}{
+		{name: "OK#1", args: []string{"-c=" + jsonFile},
+			check: func(c Config) bool {
+				return c.Host == addr && c.DB == "file" && c.RefreshInterval == 5 && c.Limits.Burst == 3 && c.Limits.MaxUploadBytes == defaultMaxUploadBytes
+			}},
+		{name: "OK#2", args: []string{"-c", yamlFile},
+			check: func(c Config) bool { return c.RefreshInterval == 10 && c.Limits.Burst == 4 && len(c.TLSHosts) == 2 }},
+		{name: "OK#3", env: map[string]string{"CONFIG_FILE": tomlFile},
+			check: func(c Config) bool {
+				return c.DB == "file" && c.Auth.Issuer == "sso" && c.Limits.MaxUploadBytes == defaultMaxUploadBytes
+			}},
+		{name: "OK#4", args: []string{"-c=" + jsonFile},
+			env: map[string]string{"DATABASE_DSN": "env", "LIMITS_BURST": "7", "TLS_HOSTS": "a, b", "SVG_GEN": "true",
+				"AUTH_API_KEYS": `[{"name": "ops", "key": "k", "role": "admin"}]`},
+			check: func(c Config) bool {
+				return c.DB == "env" && c.Limits.Burst == 7 && c.TLSHosts[1] == "b" && c.SvgGen && c.Auth.APIKeys[0].Role == "admin"
+			}},
+		{name: "OK#5", args: []string{"-c=" + jsonFile, "-d=flag", "-r=1"}, env: map[string]string{"DATABASE_DSN": "env", "REFRESH_INTERVAL": "2"},
+			check: func(c Config) bool { return c.DB == "flag" && c.RefreshInterval == 1 }},
+		{name: "OK#6", args: []string{"-print-config"}, check: func(c Config) bool { return c.PrintConfig }},
+		{name: "OK#7", args: []string{"-c=" + schemaFile},
+			check: func(c Config) bool {
+				s := c.Parser.Schemas
+				return len(s) == 1 && s[0].Key == "device" && s[0].Columns[1].Type == "int" && s[0].Columns[1].DBColumn() == "code"
+			}},
+		{name: "OK#8", args: []string{"-c", yamlFile}, env: map[string]string{"LIMITS_MAX_UPLOAD_BYTES": "0"},
+			check: func(c Config) bool { return c.Limits.MaxUploadBytes == 0 && c.Limits.Burst == 4 }},
+		{name: "BAD#1", args: []string{"-f=" + dir, "-t=" + dir}, wantErr: "dsn is required"},
+		{name: "BAD#2", args: []string{"-d=db", "-f=" + filepath.Join(dir, "none"), "-t=" + jsonFile}, wantErr: "dir_from"},
+		{name: "BAD#3", args: []string{"-d=db", "-f=" + dir, "-t=" + jsonFile}, wantErr: "is not a directory"},
+		{name: "BAD#4", args: []string{"-c=" + jsonFile, "-r=0"}, wantErr: "refresh_interval"},
+		{name: "BAD#5", args: []string{"-c=" + writeFile(t, "bad.json", `{"dns": "typo"}`)}, wantErr: "unknown field"},
+		{name: "BAD#6", args: []string{"-c=" + writeFile(t, "config.ini", "")}, wantErr: "unknown format"},
+		{name: "BAD#7", args: []string{"-c=" + jsonFile}, env: map[string]string{"REFRESH_INTERVAL": "often"}, wantErr: "REFRESH_INTERVAL"},
+		{name: "BAD#8", args: []string{"-c=" + badSchemaFile}, wantErr: `reserved table "jobs"`},
+		{name: "BAD#9", args: []string{"-c=" + jsonFile}, env: map[string]string{"PARSER_ENCODING": "latin1"}, wantErr: "parser.encoding"},
+		{name: "BAD#10", args: []string{"-c=" + jsonFile}, env: map[string]string{"LIMITS_TRUSTED_PROXIES": "10.0.0.0/8, proxy"},
+			wantErr: `limits.trusted_proxies: bad ip or cidr "proxy"`},
+		{name: "BAD#11", args: []string{"-c=" + jsonFile}, env: map[string]string{"TLS_MIN_VERSION": "1.0"}, wantErr: `tls_min_version: unsupported version "1.0"`},
+		{name: "BAD#12", args: []string{"-c=" + jsonFile}, env: map[string]string{"TLS_CIPHERS": "TLS_AES_128_GCM_SHA256,TLS_RC4"},
+			wantErr: `tls_ciphers: unsupported cipher suite "TLS_RC4"`},
+		{name: "BAD#13", args: []string{"-c=" + jsonFile}, env: map[string]string{"LOG_LEVEL": "verbose"}, wantErr: `log.level: unknown level "verbose"`},
+		{name: "BAD#14", args: []string{"-c=" + jsonFile}, env: map[string]string{"LOG_FORMAT": "text"}, wantErr: `log.format: unknown format "text"`},
+		{name: "BAD#15", args: []string{"-c=" + jsonFile}, env: map[string]string{"TRACING_EXPORTER": "jaeger"}, wantErr: `tracing.exporter: unknown exporter "jaeger"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := Load(tt.args, env(tt.env))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("got %v, want error with %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !tt.check(c) {
+				t.Errorf("unexpected config %+v", c)
+			}
+		})
+	}
+}
+
+func TestConfig_Print(t *testing.T) {
+	tests := []struct {
+		name string
+		dsn  string
+		want string
+	}{
+		{name: "OK#1", dsn: "postgres://user:secret@db:5432/dbname", want: "postgres://user:xxxxx@db:5432/dbname"},
+		{name: "OK#2", dsn: "host=db user=user password=secret dbname=dbname", want: "host=db user=user password=xxxxx dbname=dbname"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Config{DB: tt.dsn, Auth: Auth{APIKeys: []APIKey{{Name: "ops", Key: "secret", Role: "admin"}}}}
+			var out bytes.Buffer
+			if err := c.Print(&out); err != nil {
+				t.Fatal(err)
+			}
+			if strings.Contains(out.String(), "secret") || !strings.Contains(out.String(), tt.want) {
+				t.Errorf("secrets are not redacted: %s", out.String())
+			}
+			if c.Auth.APIKeys[0].Key != "secret" {
+				t.Errorf("config is changed")
+			}
+		})
+	}
+}
+
+func TestChanged(t *testing.T) {
+	a := Config{Host: addr, TLSHosts: []string{"a"}, Log: Log{Level: "info"}, CFile: "a.json"}
+	b := Config{Host: addr, TLSHosts: []string{"b"}, Log: Log{Level: "debug"}, CFile: "b.json"}
+	want := []string{"tls_hosts", "log.level"}
+	if got := Changed(a, b); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got := Changed(a, a); len(got) != 0 {
+		t.Errorf("got %v for the same config", got)
+	}
+}
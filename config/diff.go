@@ -0,0 +1,31 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Changed list keys which differ between configs, nested keys are joined with dot, e.g. log.level
+func Changed(a, b Config) []string {
+	return changed(reflect.ValueOf(a), reflect.ValueOf(b), "")
+}
+
+func changed(a, b reflect.Value, prefix string) []string {
+	var keys []string
+	t := a.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if f.Type.Kind() == reflect.Struct {
+			keys = append(keys, changed(a.Field(i), b.Field(i), prefix+name+".")...)
+			continue
+		}
+		if !reflect.DeepEqual(a.Field(i).Interface(), b.Field(i).Interface()) {
+			keys = append(keys, prefix+name)
+		}
+	}
+	return keys
+}
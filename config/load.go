@@ -0,0 +1,184 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	addr                   = "localhost:8080"
+	defaultRefreshInterval = 10
+	// defaultMaxUploadBytes keeps uploads from filling disk when limits are not set, 0 turns it off
+	defaultMaxUploadBytes = 100 << 20
+)
+
+// New load config of process from args and environment, see Load
+func New() (Config, error) {
+	return Load(os.Args[1:], os.LookupEnv)
+}
+
+// Load merge defaults < file < env < flags and validate result.
+// File is taken from -c or CONFIG_FILE, its format from extension: .json, .yaml, .yml or .toml.
+// Config is not validated with -print-config, so broken config can be inspected
+func Load(args []string, lookupEnv func(string) (string, bool)) (Config, error) {
+	// first pass only finds config file, flags are applied again over file and env
+	var first Config
+	if err := flags(&first).Parse(args); err != nil {
+		return Config{}, err
+	}
+
+	c := Config{Host: addr, RefreshInterval: defaultRefreshInterval, Parser: Parser{Filters: slices.Clone(DefaultFilters), Formats: maps.Clone(DefaultFormats)},
+		Limits: Limits{MaxUploadBytes: defaultMaxUploadBytes}}
+	c.CFile = first.CFile
+	if c.CFile == "" {
+		c.CFile, _ = lookupEnv("CONFIG_FILE")
+	}
+	if c.CFile != "" {
+		if err := readFile(c.CFile, &c); err != nil {
+			return Config{}, fmt.Errorf("failed to read config file %s: %w", c.CFile, err)
+		}
+	}
+	if err := readEnv(reflect.ValueOf(&c).Elem(), "", lookupEnv); err != nil {
+		return Config{}, err
+	}
+	if err := flags(&c).Parse(args); err != nil {
+		return Config{}, err
+	}
+
+	if c.PrintConfig {
+		return c, nil
+	}
+	return c, c.Validate()
+}
+
+// flags set fields of c, only flags given in args overwrite them
+func flags(c *Config) *flag.FlagSet {
+	fs := flag.NewFlagSet("goTSVParser", flag.ContinueOnError)
+	fs.StringVar(&c.Host, "a", c.Host, "-a=host:port")
+	fs.BoolVar(&c.TLS, "tls", c.TLS, "-tls=")
+	fs.StringVar(&c.Certificate, "cert", c.Certificate, "-cert=path_to_certificate")
+	fs.StringVar(&c.PrivateKey, "key", c.PrivateKey, "-key=path_to_key")
+	fs.StringVar(&c.DirectoryFrom, "f", c.DirectoryFrom, "-f=from")
+	fs.StringVar(&c.DB, "d", c.DB, "-d=db")
+	fs.StringVar(&c.DirectoryTo, "t", c.DirectoryTo, "-t=to")
+	fs.IntVar(&c.RefreshInterval, "r", c.RefreshInterval, "interval of check")
+	fs.BoolVar(&c.SvgGen, "svg", c.SvgGen, "-svg=")
+	fs.StringVar(&c.CFile, "c", c.CFile, "config file .json, .yaml or .toml")
+	fs.BoolVar(&c.PrintConfig, "print-config", false, "print merged config with secrets redacted and exit")
+	return fs
+}
+
+// readFile decode yaml and toml through json, so json tags are the only names of fields
+func readFile(path string, c *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var raw interface{}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &raw)
+	case ".toml":
+		err = toml.Unmarshal(data, &raw)
+	default:
+		return fmt.Errorf("unknown format %q, want .json, .yaml, .yml or .toml", ext)
+	}
+	if err != nil {
+		return err
+	}
+	if raw != nil {
+		if data, err = json.Marshal(raw); err != nil {
+			return err
+		}
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(c)
+}
+
+// envName is variable of field, env tag or path of json tags, e.g. LIMITS_BURST
+func envName(f reflect.StructField, prefix string) string {
+	if name := f.Tag.Get("env"); name != "" {
+		return name
+	}
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	return prefix + strings.ToUpper(name)
+}
+
+// readEnv set fields from not empty env vars, lists are comma separated, objects are json
+func readEnv(v reflect.Value, prefix string, lookupEnv func(string) (string, bool)) error {
+	var errs []error
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Tag.Get("json") == "-" {
+			continue
+		}
+		name := envName(f, prefix)
+		if f.Type.Kind() == reflect.Struct {
+			errs = append(errs, readEnv(v.Field(i), name+"_", lookupEnv))
+			continue
+		}
+		value, ok := lookupEnv(name)
+		if !ok || value == "" {
+			continue
+		}
+		if err := setField(v.Field(i), value); err != nil {
+			errs = append(errs, fmt.Errorf("bad %s: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func setField(v reflect.Value, value string) error {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Float64:
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(n)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.String {
+			list := strings.Split(value, ",")
+			for i := range list {
+				list[i] = strings.TrimSpace(list[i])
+			}
+			v.Set(reflect.ValueOf(list))
+			return nil
+		}
+		return json.Unmarshal([]byte(value), v.Addr().Interface())
+	default:
+		return json.Unmarshal([]byte(value), v.Addr().Interface())
+	}
+	return nil
+}
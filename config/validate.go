@@ -0,0 +1,213 @@
+package config
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go.uber.org/zap/zapcore"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Validate check config before start, all problems are reported at once
+func (c Config) Validate() error {
+	var errs []error
+	if c.DB == "" {
+		errs = append(errs, errors.New("dsn is required (dsn, DATABASE_DSN or -d)"))
+	}
+	errs = append(errs, directory("dir_from", c.DirectoryFrom), directory("dir_to", c.DirectoryTo))
+	if c.RefreshInterval <= 0 {
+		errs = append(errs, fmt.Errorf("refresh_interval must be positive number of seconds, got %d", c.RefreshInterval))
+	}
+	if c.ShutdownTimeout < 0 {
+		errs = append(errs, fmt.Errorf("shutdown_timeout must not be negative, got %d", c.ShutdownTimeout))
+	}
+	if (c.Certificate == "") != (c.PrivateKey == "") {
+		errs = append(errs, errors.New("certificate and private must be set together"))
+	}
+	errs = append(errs,
+		file("certificate", c.Certificate),
+		file("private", c.PrivateKey),
+		file("auth.jwks", c.Auth.JWKS),
+		file("auth.client_ca", c.Auth.ClientCA),
+	)
+	if !slices.Contains(tlsVersions, c.TLSMinVersion) {
+		errs = append(errs, fmt.Errorf("tls_min_version: unsupported version %q, want 1.2 or 1.3", c.TLSMinVersion))
+	}
+	for _, name := range c.TLSCiphers {
+		if !slices.ContainsFunc(tls.CipherSuites(), func(s *tls.CipherSuite) bool { return s.Name == name }) {
+			errs = append(errs, fmt.Errorf("tls_ciphers: unsupported cipher suite %q", name))
+		}
+	}
+	if _, err := zapcore.ParseLevel(c.Log.Level); c.Log.Level != "" && err != nil {
+		errs = append(errs, fmt.Errorf("log.level: unknown level %q", c.Log.Level))
+	}
+	if !slices.Contains(logFormats, c.Log.Format) {
+		errs = append(errs, fmt.Errorf("log.format: unknown format %q, want json or console", c.Log.Format))
+	}
+	if !slices.Contains(exporters, c.Tracing.Exporter) {
+		errs = append(errs, fmt.Errorf("tracing.exporter: unknown exporter %q, want otlp or stdout", c.Tracing.Exporter))
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		errs = append(errs, fmt.Errorf("tracing.sample_ratio must be from 0 to 1, got %v", c.Tracing.SampleRatio))
+	}
+	v := c.Parser.Validation
+	errs = append(errs, validRange("parser.validation.number", v.Number), validRange("parser.validation.level", v.Level),
+		validRange("parser.validation.bit", v.Bit))
+	errs = append(errs, validSchemas(c.Parser.Schemas)...)
+	if !slices.Contains(encodings, c.Parser.Encoding) {
+		errs = append(errs, fmt.Errorf("parser.encoding: unknown encoding %q", c.Parser.Encoding))
+	}
+	exts := make([]string, 0, len(c.Parser.Formats))
+	for ext := range c.Parser.Formats {
+		exts = append(exts, ext)
+	}
+	slices.Sort(exts)
+	for _, ext := range exts {
+		if format := c.Parser.Formats[ext]; !strings.HasPrefix(ext, ".") || !slices.Contains(inputFormats, format) {
+			errs = append(errs, fmt.Errorf("parser.formats: bad extension %q or format %q", ext, format))
+		}
+	}
+	l := c.Limits
+	if l.RatePerSecond < 0 || l.Burst < 0 || l.MaxBodyBytes < 0 || l.MaxUploadBytes < 0 ||
+		l.MaxPageSize < 0 || l.MaxReportRows < 0 || l.RequestTimeout < 0 || l.UploadTimeout < 0 {
+		errs = append(errs, errors.New("limits must not be negative"))
+	}
+	for _, p := range l.TrustedProxies {
+		if _, _, err := net.ParseCIDR(p); err != nil && net.ParseIP(p) == nil {
+			errs = append(errs, fmt.Errorf("limits.trusted_proxies: bad ip or cidr %q", p))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func directory(name, path string) error {
+	if path == "" {
+		return fmt.Errorf("%s is required", name)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s: %s is not a directory", name, path)
+	}
+	return nil
+}
+
+func validRange(name string, r Range) error {
+	if r.Min != nil && r.Max != nil && *r.Min > *r.Max {
+		return fmt.Errorf("%s: min %d is greater than max %d", name, *r.Min, *r.Max)
+	}
+	return nil
+}
+
+// identifier of table or column, schemas come from config so they are put into queries as is
+var identifier = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+// reservedTables belong to service
+var reservedTables = []string{"occurrence", "jobs", "checkedfiles", "checkedfileswitherr", "schema_migrations",
+	"occurrence_types_backup"}
+
+// tlsVersions, logFormats and exporters are values known by server, logger and tracing, empty is default
+var tlsVersions = []string{"", "1.2", "1.3"}
+
+var logFormats = []string{"", "json", "console"}
+
+var exporters = []string{"", "otlp", "stdout"}
+
+var encodings = []string{"", "auto", "utf-8", "cp1251", "windows-1251", "koi8-r", "utf-16le", "utf-16be"}
+
+var inputFormats = []string{"tsv", "csv", "xlsx", "jsonl"}
+
+var columnTypes = []string{"", "string", "text", "int", "bool", "guid"}
+
+func validSchemas(schemas []Schema) []error {
+	var errs []error
+	names, tables := make(map[string]bool), make(map[string]bool)
+	for i, s := range schemas {
+		name := fmt.Sprintf("parser.schemas.%d", i)
+		if s.Name == "" || s.Name == "occurrence" || names[s.Name] {
+			errs = append(errs, fmt.Errorf("%s: name must be unique, not empty and not occurrence, got %q", name, s.Name))
+		}
+		names[s.Name] = true
+		if _, err := filepath.Match(s.Match, ""); s.Match == "" || err != nil {
+			errs = append(errs, fmt.Errorf("%s: bad match %q", name, s.Match))
+		}
+		if !identifier.MatchString(s.Table) || slices.Contains(reservedTables, s.Table) || tables[s.Table] {
+			errs = append(errs, fmt.Errorf("%s: bad or reserved table %q", name, s.Table))
+		}
+		tables[s.Table] = true
+		if !slices.Contains(encodings, s.Encoding) {
+			errs = append(errs, fmt.Errorf("%s: unknown encoding %q", name, s.Encoding))
+		}
+		if len(s.Columns) == 0 {
+			errs = append(errs, fmt.Errorf("%s: columns are required", name))
+		}
+		columns, key := make(map[string]bool), false
+		for j, c := range s.Columns {
+			column := fmt.Sprintf("%s.columns.%d", name, j)
+			if c.Name == "" || columns[c.Name] {
+				errs = append(errs, fmt.Errorf("%s: name must be unique and not empty, got %q", column, c.Name))
+			}
+			columns[c.Name] = true
+			if db := c.DBColumn(); !identifier.MatchString(db) || db == "id" || db == "file" {
+				errs = append(errs, fmt.Errorf("%s: bad or reserved db column %q", column, db))
+			}
+			if !slices.Contains(columnTypes, c.Type) {
+				errs = append(errs, fmt.Errorf("%s: unknown type %q", column, c.Type))
+			}
+			errs = append(errs, validRange(column, Range{Min: c.Min, Max: c.Max}))
+			key = key || c.Name == s.Key
+		}
+		if !key {
+			errs = append(errs, fmt.Errorf("%s: key %q is not a column", name, s.Key))
+		}
+	}
+	return errs
+}
+
+// file check optional file, empty path is fine
+func file(name, path string) error {
+	if path == "" {
+		return nil
+	}
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+const redacted = "xxxxx"
+
+var dsnPassword = regexp.MustCompile(`(password\s*=\s*)('[^']*'|\S+)`)
+
+// Redacted copy of config without dsn password and api keys
+func (c Config) Redacted() Config {
+	if u, err := url.Parse(c.DB); err == nil && u.User != nil {
+		c.DB = u.Redacted()
+	} else {
+		c.DB = dsnPassword.ReplaceAllString(c.DB, "${1}"+redacted)
+	}
+	keys := make([]APIKey, len(c.Auth.APIKeys))
+	for i, k := range c.Auth.APIKeys {
+		k.Key = redacted
+		keys[i] = k
+	}
+	c.Auth.APIKeys = keys
+	return c
+}
+
+// Print write redacted config as json, it can be used as config file
+func (c Config) Print(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(c.Redacted())
+}
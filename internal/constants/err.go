@@ -1,8 +1,33 @@
 package constants
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var (
-	ErrNotTSV   = errors.New("not a tsv file")
-	ErrNotFound = errors.New("not found")
+	ErrNotTSV            = errors.New("unsupported input file")
+	ErrNotFound          = errors.New("not found")
+	ErrUnsupportedFormat = errors.New("unsupported report format")
+	ErrInvalidTSV        = errors.New("invalid input file")
+	ErrInvalidRequest    = errors.New("invalid request")
+	ErrBadRequest        = errors.New("bad request")
+	ErrUnavailable       = errors.New("service unavailable")
+	ErrUnauthorized      = errors.New("unauthorized")
+	ErrForbidden         = errors.New("forbidden")
+	ErrRateLimited       = errors.New("too many requests")
 )
+
+// ValidationError is ErrInvalidRequest with name of invalid field
+type ValidationError struct {
+	Field  string `json:"field"`
+	Reason string `json:"reason"`
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s %s", ErrInvalidRequest, e.Field, e.Reason)
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrInvalidRequest
+}
@@ -4,6 +4,7 @@ package mocks
 
 import (
 	context "context"
+	io "io"
 
 	mock "github.com/stretchr/testify/mock"
 
@@ -16,20 +17,230 @@ type Service struct {
 }
 
 // GetAll provides a mock function with given fields: ctx, r
-func (_m *Service) GetAll(ctx context.Context, r shema.Request) ([][]shema.Tsv, error) {
+func (_m *Service) GetAll(ctx context.Context, r shema.Request) (shema.Page[shema.Tsv], error) {
 	ret := _m.Called(ctx, r)
 
-	var r0 [][]shema.Tsv
-	if rf, ok := ret.Get(0).(func(context.Context, shema.Request) [][]shema.Tsv); ok {
+	var r0 shema.Page[shema.Tsv]
+	if rf, ok := ret.Get(0).(func(context.Context, shema.Request) shema.Page[shema.Tsv]); ok {
 		r0 = rf(ctx, r)
+	} else {
+		r0 = ret.Get(0).(shema.Page[shema.Tsv])
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, shema.Request) error); ok {
+		r1 = rf(ctx, r)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetInventory provides a mock function with given fields: ctx, f
+func (_m *Service) GetInventory(ctx context.Context, f shema.CatalogFilter) (shema.Page[shema.CatalogItem], error) {
+	ret := _m.Called(ctx, f)
+
+	var r0 shema.Page[shema.CatalogItem]
+	if rf, ok := ret.Get(0).(func(context.Context, shema.CatalogFilter) shema.Page[shema.CatalogItem]); ok {
+		r0 = rf(ctx, f)
+	} else {
+		r0 = ret.Get(0).(shema.Page[shema.CatalogItem])
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, shema.CatalogFilter) error); ok {
+		r1 = rf(ctx, f)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetJob provides a mock function with given fields: ctx, id
+func (_m *Service) GetJob(ctx context.Context, id string) (shema.Job, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 shema.Job
+	if rf, ok := ret.Get(0).(func(context.Context, string) shema.Job); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(shema.Job)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetJobs provides a mock function with given fields: ctx, f
+func (_m *Service) GetJobs(ctx context.Context, f shema.JobFilter) (shema.Page[shema.Job], error) {
+	ret := _m.Called(ctx, f)
+
+	var r0 shema.Page[shema.Job]
+	if rf, ok := ret.Get(0).(func(context.Context, shema.JobFilter) shema.Page[shema.Job]); ok {
+		r0 = rf(ctx, f)
+	} else {
+		r0 = ret.Get(0).(shema.Page[shema.Job])
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, shema.JobFilter) error); ok {
+		r1 = rf(ctx, f)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetRecords provides a mock function with given fields: ctx, schema, key, r
+func (_m *Service) GetRecords(ctx context.Context, schema string, key string, r shema.RecordRequest) (shema.Page[map[string]interface{}], error) {
+	ret := _m.Called(ctx, schema, key, r)
+
+	var r0 shema.Page[map[string]interface{}]
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, shema.RecordRequest) shema.Page[map[string]interface{}]); ok {
+		r0 = rf(ctx, schema, key, r)
+	} else {
+		r0 = ret.Get(0).(shema.Page[map[string]interface{}])
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, shema.RecordRequest) error); ok {
+		r1 = rf(ctx, schema, key, r)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetReport provides a mock function with given fields: ctx, schema, key, format, ifNoneMatch
+func (_m *Service) GetReport(ctx context.Context, schema string, key string, format string, ifNoneMatch string) (shema.Report, error) {
+	ret := _m.Called(ctx, schema, key, format, ifNoneMatch)
+
+	var r0 shema.Report
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, string) shema.Report); ok {
+		r0 = rf(ctx, schema, key, format, ifNoneMatch)
+	} else {
+		r0 = ret.Get(0).(shema.Report)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string, string) error); ok {
+		r1 = rf(ctx, schema, key, format, ifNoneMatch)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetSchemas provides a mock function with given fields: ctx
+func (_m *Service) GetSchemas(ctx context.Context) []shema.SchemaInfo {
+	ret := _m.Called(ctx)
+
+	var r0 []shema.SchemaInfo
+	if rf, ok := ret.Get(0).(func(context.Context) []shema.SchemaInfo); ok {
+		r0 = rf(ctx)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([][]shema.Tsv)
+			r0 = ret.Get(0).([]shema.SchemaInfo)
 		}
 	}
 
+	return r0
+}
+
+// GetStats provides a mock function with given fields: ctx, f
+func (_m *Service) GetStats(ctx context.Context, f shema.StatsFilter) (shema.Stats, error) {
+	ret := _m.Called(ctx, f)
+
+	var r0 shema.Stats
+	if rf, ok := ret.Get(0).(func(context.Context, shema.StatsFilter) shema.Stats); ok {
+		r0 = rf(ctx, f)
+	} else {
+		r0 = ret.Get(0).(shema.Stats)
+	}
+
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, shema.Request) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, shema.StatsFilter) error); ok {
+		r1 = rf(ctx, f)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUnits provides a mock function with given fields: ctx, f
+func (_m *Service) GetUnits(ctx context.Context, f shema.CatalogFilter) (shema.Page[shema.CatalogItem], error) {
+	ret := _m.Called(ctx, f)
+
+	var r0 shema.Page[shema.CatalogItem]
+	if rf, ok := ret.Get(0).(func(context.Context, shema.CatalogFilter) shema.Page[shema.CatalogItem]); ok {
+		r0 = rf(ctx, f)
+	} else {
+		r0 = ret.Get(0).(shema.Page[shema.CatalogItem])
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, shema.CatalogFilter) error); ok {
+		r1 = rf(ctx, f)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Liveness provides a mock function with given fields: ctx
+func (_m *Service) Liveness(ctx context.Context) shema.Health {
+	ret := _m.Called(ctx)
+
+	var r0 shema.Health
+	if rf, ok := ret.Get(0).(func(context.Context) shema.Health); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(shema.Health)
+	}
+
+	return r0
+}
+
+// Readiness provides a mock function with given fields: ctx
+func (_m *Service) Readiness(ctx context.Context) shema.Health {
+	ret := _m.Called(ctx)
+
+	var r0 shema.Health
+	if rf, ok := ret.Get(0).(func(context.Context) shema.Health); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(shema.Health)
+	}
+
+	return r0
+}
+
+// Search provides a mock function with given fields: ctx, r
+func (_m *Service) Search(ctx context.Context, r shema.SearchRequest) (shema.Page[map[string]interface{}], error) {
+	ret := _m.Called(ctx, r)
+
+	var r0 shema.Page[map[string]interface{}]
+	if rf, ok := ret.Get(0).(func(context.Context, shema.SearchRequest) shema.Page[map[string]interface{}]); ok {
+		r0 = rf(ctx, r)
+	} else {
+		r0 = ret.Get(0).(shema.Page[map[string]interface{}])
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, shema.SearchRequest) error); ok {
 		r1 = rf(ctx, r)
 	} else {
 		r1 = ret.Error(1)
@@ -38,6 +249,27 @@ func (_m *Service) GetAll(ctx context.Context, r shema.Request) ([][]shema.Tsv,
 	return r0, r1
 }
 
+// Upload provides a mock function with given fields: ctx, fileName, r
+func (_m *Service) Upload(ctx context.Context, fileName string, r io.Reader) (shema.Job, error) {
+	ret := _m.Called(ctx, fileName, r)
+
+	var r0 shema.Job
+	if rf, ok := ret.Get(0).(func(context.Context, string, io.Reader) shema.Job); ok {
+		r0 = rf(ctx, fileName, r)
+	} else {
+		r0 = ret.Get(0).(shema.Job)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, io.Reader) error); ok {
+		r1 = rf(ctx, fileName, r)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Worker provides a mock function with given fields: ctx
 func (_m *Service) Worker(ctx context.Context) error {
 	ret := _m.Called(ctx)
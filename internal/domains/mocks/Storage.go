@@ -7,6 +7,8 @@ import (
 
 	mock "github.com/stretchr/testify/mock"
 
+	config "goTSVParser/config"
+
 	shema "goTSVParser/internal/shema"
 )
 
@@ -15,27 +17,76 @@ type Storage struct {
 	mock.Mock
 }
 
-// GetAllGuids provides a mock function with given fields: ctx, unitGuid
-func (_m *Storage) GetAllGuids(ctx context.Context, unitGuid string) ([]shema.Tsv, error) {
-	ret := _m.Called(ctx, unitGuid)
+// CheckConnection provides a mock function with given fields: ctx
+func (_m *Storage) CheckConnection(ctx context.Context) error {
+	ret := _m.Called(ctx)
 
-	var r0 []shema.Tsv
-	if rf, ok := ret.Get(0).(func(context.Context, string) []shema.Tsv); ok {
-		r0 = rf(ctx, unitGuid)
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateJob provides a mock function with given fields: ctx, job
+func (_m *Storage) CreateJob(ctx context.Context, job shema.Job) error {
+	ret := _m.Called(ctx, job)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, shema.Job) error); ok {
+		r0 = rf(ctx, job)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateTable provides a mock function with given fields: ctx, schema
+func (_m *Storage) CreateTable(ctx context.Context, schema config.Schema) error {
+	ret := _m.Called(ctx, schema)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, config.Schema) error); ok {
+		r0 = rf(ctx, schema)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetCatalog provides a mock function with given fields: ctx, field, f
+func (_m *Storage) GetCatalog(ctx context.Context, field string, f shema.CatalogFilter) ([]shema.CatalogItem, int, error) {
+	ret := _m.Called(ctx, field, f)
+
+	var r0 []shema.CatalogItem
+	if rf, ok := ret.Get(0).(func(context.Context, string, shema.CatalogFilter) []shema.CatalogItem); ok {
+		r0 = rf(ctx, field, f)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]shema.Tsv)
+			r0 = ret.Get(0).([]shema.CatalogItem)
 		}
 	}
 
-	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = rf(ctx, unitGuid)
+	var r1 int
+	if rf, ok := ret.Get(1).(func(context.Context, string, shema.CatalogFilter) int); ok {
+		r1 = rf(ctx, field, f)
 	} else {
-		r1 = ret.Error(1)
+		r1 = ret.Get(1).(int)
 	}
 
-	return r0, r1
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, string, shema.CatalogFilter) error); ok {
+		r2 = rf(ctx, field, f)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
 }
 
 // GetCheckedFiles provides a mock function with given fields:
@@ -61,13 +112,189 @@ func (_m *Storage) GetCheckedFiles() ([]shema.ParsedFiles, error) {
 	return r0, r1
 }
 
-// Save provides a mock function with given fields: sh
-func (_m *Storage) Save(sh shema.Tsv) error {
-	ret := _m.Called(sh)
+// GetJob provides a mock function with given fields: ctx, id
+func (_m *Storage) GetJob(ctx context.Context, id string) (shema.Job, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 shema.Job
+	if rf, ok := ret.Get(0).(func(context.Context, string) shema.Job); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(shema.Job)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetJobs provides a mock function with given fields: ctx, f
+func (_m *Storage) GetJobs(ctx context.Context, f shema.JobFilter) ([]shema.Job, int, error) {
+	ret := _m.Called(ctx, f)
+
+	var r0 []shema.Job
+	if rf, ok := ret.Get(0).(func(context.Context, shema.JobFilter) []shema.Job); ok {
+		r0 = rf(ctx, f)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]shema.Job)
+		}
+	}
+
+	var r1 int
+	if rf, ok := ret.Get(1).(func(context.Context, shema.JobFilter) int); ok {
+		r1 = rf(ctx, f)
+	} else {
+		r1 = ret.Get(1).(int)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, shema.JobFilter) error); ok {
+		r2 = rf(ctx, f)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetOccurrences provides a mock function with given fields: ctx, q
+func (_m *Storage) GetOccurrences(ctx context.Context, q shema.OccurrenceQuery) ([]shema.Tsv, int, error) {
+	ret := _m.Called(ctx, q)
+
+	var r0 []shema.Tsv
+	if rf, ok := ret.Get(0).(func(context.Context, shema.OccurrenceQuery) []shema.Tsv); ok {
+		r0 = rf(ctx, q)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]shema.Tsv)
+		}
+	}
+
+	var r1 int
+	if rf, ok := ret.Get(1).(func(context.Context, shema.OccurrenceQuery) int); ok {
+		r1 = rf(ctx, q)
+	} else {
+		r1 = ret.Get(1).(int)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, shema.OccurrenceQuery) error); ok {
+		r2 = rf(ctx, q)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetRecords provides a mock function with given fields: ctx, schema, key, lastID
+func (_m *Storage) GetRecords(ctx context.Context, schema config.Schema, key string, lastID int64) ([]shema.Record, error) {
+	ret := _m.Called(ctx, schema, key, lastID)
+
+	var r0 []shema.Record
+	if rf, ok := ret.Get(0).(func(context.Context, config.Schema, string, int64) []shema.Record); ok {
+		r0 = rf(ctx, schema, key, lastID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]shema.Record)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, config.Schema, string, int64) error); ok {
+		r1 = rf(ctx, schema, key, lastID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetRecordsPage provides a mock function with given fields: ctx, schema, key, limit, offset
+func (_m *Storage) GetRecordsPage(ctx context.Context, schema config.Schema, key string, limit int, offset int) ([]shema.Record, int, error) {
+	ret := _m.Called(ctx, schema, key, limit, offset)
+
+	var r0 []shema.Record
+	if rf, ok := ret.Get(0).(func(context.Context, config.Schema, string, int, int) []shema.Record); ok {
+		r0 = rf(ctx, schema, key, limit, offset)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]shema.Record)
+		}
+	}
+
+	var r1 int
+	if rf, ok := ret.Get(1).(func(context.Context, config.Schema, string, int, int) int); ok {
+		r1 = rf(ctx, schema, key, limit, offset)
+	} else {
+		r1 = ret.Get(1).(int)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, config.Schema, string, int, int) error); ok {
+		r2 = rf(ctx, schema, key, limit, offset)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetReportVersion provides a mock function with given fields: ctx, schema, key
+func (_m *Storage) GetReportVersion(ctx context.Context, schema config.Schema, key string) (shema.ReportVersion, error) {
+	ret := _m.Called(ctx, schema, key)
+
+	var r0 shema.ReportVersion
+	if rf, ok := ret.Get(0).(func(context.Context, config.Schema, string) shema.ReportVersion); ok {
+		r0 = rf(ctx, schema, key)
+	} else {
+		r0 = ret.Get(0).(shema.ReportVersion)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, config.Schema, string) error); ok {
+		r1 = rf(ctx, schema, key)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetStats provides a mock function with given fields: ctx, q, limit
+func (_m *Storage) GetStats(ctx context.Context, q shema.OccurrenceQuery, limit int) (shema.Stats, error) {
+	ret := _m.Called(ctx, q, limit)
+
+	var r0 shema.Stats
+	if rf, ok := ret.Get(0).(func(context.Context, shema.OccurrenceQuery, int) shema.Stats); ok {
+		r0 = rf(ctx, q, limit)
+	} else {
+		r0 = ret.Get(0).(shema.Stats)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, shema.OccurrenceQuery, int) error); ok {
+		r1 = rf(ctx, q, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Save provides a mock function with given fields: ctx, schema, rows
+func (_m *Storage) Save(ctx context.Context, schema config.Schema, rows []shema.Record) error {
+	ret := _m.Called(ctx, schema, rows)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(shema.Tsv) error); ok {
-		r0 = rf(sh)
+	if rf, ok := ret.Get(0).(func(context.Context, config.Schema, []shema.Record) error); ok {
+		r0 = rf(ctx, schema, rows)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -75,13 +302,13 @@ func (_m *Storage) Save(sh shema.Tsv) error {
 	return r0
 }
 
-// SaveFiles provides a mock function with given fields: fileName
-func (_m *Storage) SaveFiles(fileName string) error {
-	ret := _m.Called(fileName)
+// SaveFiles provides a mock function with given fields: ctx, fileName
+func (_m *Storage) SaveFiles(ctx context.Context, fileName string) error {
+	ret := _m.Called(ctx, fileName)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string) error); ok {
-		r0 = rf(fileName)
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, fileName)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -89,13 +316,13 @@ func (_m *Storage) SaveFiles(fileName string) error {
 	return r0
 }
 
-// SaveFilesWithErr provides a mock function with given fields: sh
-func (_m *Storage) SaveFilesWithErr(sh shema.Files) error {
-	ret := _m.Called(sh)
+// SaveFilesWithErr provides a mock function with given fields: ctx, sh
+func (_m *Storage) SaveFilesWithErr(ctx context.Context, sh shema.Files) error {
+	ret := _m.Called(ctx, sh)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(shema.Files) error); ok {
-		r0 = rf(sh)
+	if rf, ok := ret.Get(0).(func(context.Context, shema.Files) error); ok {
+		r0 = rf(ctx, sh)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -117,6 +344,41 @@ func (_m *Storage) ShutDown() error {
 	return r0
 }
 
+// StartJob provides a mock function with given fields: ctx, id, file
+func (_m *Storage) StartJob(ctx context.Context, id string, file string) (string, error) {
+	ret := _m.Called(ctx, id, file)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) string); ok {
+		r0 = rf(ctx, id, file)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, id, file)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateJob provides a mock function with given fields: ctx, job
+func (_m *Storage) UpdateJob(ctx context.Context, job shema.Job) error {
+	ret := _m.Called(ctx, job)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, shema.Job) error); ok {
+		r0 = rf(ctx, job)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 type mockConstructorTestingTNewStorage interface {
 	mock.TestingT
 	Cleanup(func())
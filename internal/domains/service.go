@@ -3,10 +3,23 @@ package domains
 import (
 	"context"
 	"goTSVParser/internal/shema"
+	"io"
 )
 
 //go:generate go run github.com/vektra/mockery/v3 --name=Service
 type Service interface {
 	Worker(ctx context.Context) error
-	GetAll(ctx context.Context, r shema.Request) ([][]shema.Tsv, error)
+	GetAll(ctx context.Context, r shema.Request) (shema.Page[shema.Tsv], error)
+	Search(ctx context.Context, r shema.SearchRequest) (shema.Page[map[string]interface{}], error)
+	GetStats(ctx context.Context, f shema.StatsFilter) (shema.Stats, error)
+	GetUnits(ctx context.Context, f shema.CatalogFilter) (shema.Page[shema.CatalogItem], error)
+	GetInventory(ctx context.Context, f shema.CatalogFilter) (shema.Page[shema.CatalogItem], error)
+	GetReport(ctx context.Context, schema string, key string, format string, ifNoneMatch string) (shema.Report, error)
+	GetRecords(ctx context.Context, schema string, key string, r shema.RecordRequest) (shema.Page[map[string]interface{}], error)
+	GetSchemas(ctx context.Context) []shema.SchemaInfo
+	Upload(ctx context.Context, fileName string, r io.Reader) (shema.Job, error)
+	GetJob(ctx context.Context, id string) (shema.Job, error)
+	GetJobs(ctx context.Context, f shema.JobFilter) (shema.Page[shema.Job], error)
+	Liveness(ctx context.Context) shema.Health
+	Readiness(ctx context.Context) shema.Health
 }
@@ -2,15 +2,28 @@ package domains
 
 import (
 	"context"
+	"goTSVParser/config"
 	"goTSVParser/internal/shema"
 )
 
 //go:generate go run github.com/vektra/mockery/v3 --name=Storage
 type Storage interface {
-	SaveFilesWithErr(sh shema.Files) error
-	SaveFiles(fileName string) error
-	Save(sh shema.Tsv) error
+	SaveFilesWithErr(ctx context.Context, sh shema.Files) error
+	SaveFiles(ctx context.Context, fileName string) error
+	Save(ctx context.Context, schema config.Schema, rows []shema.Record) error
+	CreateTable(ctx context.Context, schema config.Schema) error
 	GetCheckedFiles() ([]shema.ParsedFiles, error)
-	GetAllGuids(ctx context.Context, unitGuid string) ([]shema.Tsv, error)
+	CreateJob(ctx context.Context, job shema.Job) error
+	StartJob(ctx context.Context, id string, file string) (string, error)
+	UpdateJob(ctx context.Context, job shema.Job) error
+	GetJob(ctx context.Context, id string) (shema.Job, error)
+	GetJobs(ctx context.Context, f shema.JobFilter) ([]shema.Job, int, error)
+	GetReportVersion(ctx context.Context, schema config.Schema, key string) (shema.ReportVersion, error)
+	GetRecords(ctx context.Context, schema config.Schema, key string, lastID int64) ([]shema.Record, error)
+	GetRecordsPage(ctx context.Context, schema config.Schema, key string, limit, offset int) ([]shema.Record, int, error)
+	GetOccurrences(ctx context.Context, q shema.OccurrenceQuery) ([]shema.Tsv, int, error)
+	GetStats(ctx context.Context, q shema.OccurrenceQuery, limit int) (shema.Stats, error)
+	GetCatalog(ctx context.Context, field string, f shema.CatalogFilter) ([]shema.CatalogItem, int, error)
+	CheckConnection(ctx context.Context) error
 	ShutDown() error
 }
@@ -0,0 +1,305 @@
+package handler
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"goTSVParser/config"
+	"goTSVParser/internal/constants"
+	"math/big"
+	"os"
+	"strings"
+)
+
+const (
+	RoleRead  = "read"
+	RoleAdmin = "admin"
+
+	apiKeyHeader = "X-API-Key"
+)
+
+// principal is authenticated caller
+type principal struct {
+	Name string
+	Role string
+}
+
+// Auth check api keys, jwt bearer tokens and client certificates
+type Auth struct {
+	keys        map[[sha256.Size]byte]principal
+	jwks        map[string]interface{}
+	parser      *jwt.Parser
+	roleClaim   string
+	clientCAs   *x509.CertPool
+	requireCert bool
+	clientRoles map[string]string
+}
+
+func NewAuth(cfg config.Auth) (*Auth, error) {
+	a := &Auth{
+		keys:        make(map[[sha256.Size]byte]principal),
+		roleClaim:   cfg.RoleClaim,
+		requireCert: cfg.RequireCert,
+		clientRoles: cfg.ClientRoles,
+	}
+	if a.roleClaim == "" {
+		a.roleClaim = "role"
+	}
+
+	for _, k := range cfg.APIKeys {
+		if k.Key == "" || !validRole(k.Role) {
+			return nil, fmt.Errorf("api key %s must have key and role %s or %s", k.Name, RoleRead, RoleAdmin)
+		}
+		a.keys[sha256.Sum256([]byte(k.Key))] = principal{Name: k.Name, Role: k.Role}
+	}
+
+	if cfg.JWKS != "" {
+		jwks, err := loadJWKS(cfg.JWKS)
+		if err != nil {
+			return nil, err
+		}
+		a.jwks = jwks
+		opts := []jwt.ParserOption{
+			jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}),
+			jwt.WithExpirationRequired(),
+		}
+		if cfg.Issuer != "" {
+			opts = append(opts, jwt.WithIssuer(cfg.Issuer))
+		}
+		if cfg.Audience != "" {
+			opts = append(opts, jwt.WithAudience(cfg.Audience))
+		}
+		a.parser = jwt.NewParser(opts...)
+	}
+
+	if cfg.ClientCA != "" {
+		pem, err := os.ReadFile(cfg.ClientCA)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read client ca: %w", err)
+		}
+		a.clientCAs = x509.NewCertPool()
+		if !a.clientCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates in client ca %s", cfg.ClientCA)
+		}
+	}
+	for cn, role := range cfg.ClientRoles {
+		if !validRole(role) {
+			return nil, fmt.Errorf("client %s must have role %s or %s", cn, RoleRead, RoleAdmin)
+		}
+	}
+	return a, nil
+}
+
+// Enabled is false when no auth method is configured, then api is open
+func (a *Auth) Enabled() bool {
+	return len(a.keys) > 0 || a.parser != nil || a.clientCAs != nil
+}
+
+// applyTLS ask clients for certificate signed by client ca when mTLS is on
+func (a *Auth) applyTLS(c *tls.Config) {
+	if a.clientCAs == nil {
+		return
+	}
+	c.ClientCAs = a.clientCAs
+	c.ClientAuth = tls.VerifyClientCertIfGiven
+	if a.requireCert {
+		c.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+}
+
+// Require let through callers with given role, admin has every role
+func (a *Auth) Require(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !a.Enabled() {
+			c.Next()
+			return
+		}
+		p, err := a.authenticate(c)
+		if err != nil {
+			HandlerErr(c, err)
+			return
+		}
+		if p.Role != role && p.Role != RoleAdmin {
+			HandlerErr(c, fmt.Errorf("%w: %s needs role %s", constants.ErrForbidden, p.Name, role))
+			return
+		}
+		c.Set("principal", p.Name)
+		c.Next()
+	}
+}
+
+func (a *Auth) authenticate(c *gin.Context) (principal, error) {
+	if key := c.GetHeader(apiKeyHeader); key != "" {
+		return a.apiKey(key)
+	}
+
+	if token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); ok {
+		if p, err := a.apiKey(token); err == nil {
+			return p, nil
+		}
+		return a.token(token)
+	}
+
+	if tls := c.Request.TLS; tls != nil && a.clientCAs != nil && len(tls.VerifiedChains) > 0 {
+		cn := tls.VerifiedChains[0][0].Subject.CommonName
+		role := a.clientRoles[cn]
+		if role == "" {
+			role = RoleRead
+		}
+		return principal{Name: cn, Role: role}, nil
+	}
+
+	return principal{}, fmt.Errorf("%w: credentials are required", constants.ErrUnauthorized)
+}
+
+func (a *Auth) apiKey(key string) (principal, error) {
+	sum := sha256.Sum256([]byte(key))
+	for k, p := range a.keys {
+		if subtle.ConstantTimeCompare(k[:], sum[:]) == 1 {
+			return p, nil
+		}
+	}
+	return principal{}, fmt.Errorf("%w: unknown api key", constants.ErrUnauthorized)
+}
+
+func (a *Auth) token(raw string) (principal, error) {
+	if a.parser == nil {
+		return principal{}, fmt.Errorf("%w: bearer tokens are not accepted", constants.ErrUnauthorized)
+	}
+	claims := jwt.MapClaims{}
+	_, err := a.parser.ParseWithClaims(raw, claims, a.key)
+	if err != nil {
+		return principal{}, fmt.Errorf("%w: %v", constants.ErrUnauthorized, err)
+	}
+
+	name, _ := claims.GetSubject()
+	p := principal{Name: name}
+	switch roles := claims[a.roleClaim].(type) {
+	case string:
+		p.Role = roles
+	case []interface{}:
+		for _, r := range roles {
+			if r == RoleAdmin || (r == RoleRead && p.Role == "") {
+				p.Role = r.(string)
+			}
+		}
+	}
+	if !validRole(p.Role) {
+		return principal{}, fmt.Errorf("%w: token of %s has no role", constants.ErrForbidden, name)
+	}
+	return p, nil
+}
+
+// key find verification key of token by kid, token without kid is allowed when jwks has one key
+func (a *Auth) key(t *jwt.Token) (interface{}, error) {
+	kid, _ := t.Header["kid"].(string)
+	if kid == "" && len(a.jwks) == 1 {
+		for _, k := range a.jwks {
+			return k, nil
+		}
+	}
+	k, ok := a.jwks[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key %q", kid)
+	}
+	return k, nil
+}
+
+func validRole(role string) bool {
+	return role == RoleRead || role == RoleAdmin
+}
+
+type jwk struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// loadJWKS read public keys of RSA, EC and OKP types from jwks file
+func loadJWKS(path string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read jwks: %w", err)
+	}
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("failed to parse jwks: %w", err)
+	}
+
+	keys := make(map[string]interface{})
+	for _, k := range set.Keys {
+		key, err := k.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("bad key %q in jwks: %w", k.Kid, err)
+		}
+		keys[k.Kid] = key
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("jwks has no keys")
+	}
+	return keys, nil
+}
+
+func (k jwk) publicKey() (interface{}, error) {
+	var err error
+	b := func(s string) []byte {
+		v, e := base64.RawURLEncoding.DecodeString(s)
+		if e != nil {
+			err = e
+		}
+		return v
+	}
+	n, e, x, y := b(k.N), b(k.E), b(k.X), b(k.Y)
+	if err != nil {
+		return nil, err
+	}
+
+	switch k.Kty {
+	case "RSA":
+		if len(n) == 0 || len(e) == 0 {
+			return nil, errors.New("rsa key needs n and e")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %s", k.Crv)
+		}
+		key := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !curve.IsOnCurve(key.X, key.Y) {
+			return nil, errors.New("point is not on curve")
+		}
+		return key, nil
+	case "OKP":
+		if k.Crv != "Ed25519" || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("unsupported okp key %s", k.Crv)
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %s", k.Kty)
+	}
+}
@@ -0,0 +1,182 @@
+package handler
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+	"goTSVParser/config"
+	"goTSVParser/internal/domains/mocks"
+	"goTSVParser/internal/shema"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAuth(t *testing.T) {
+	dir := t.TempDir()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwks, _ := json.Marshal(map[string]interface{}{"keys": []map[string]string{{
+		"kid": "k1",
+		"kty": "RSA",
+		"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}}})
+	writeFile(t, filepath.Join(dir, "jwks.json"), jwks)
+
+	ca := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "clients"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, ca, ca, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(dir, "ca.pem"), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER}))
+
+	cnf := config.Config{Auth: config.Auth{
+		APIKeys: []config.APIKey{
+			{Name: "grafana", Key: "read-key", Role: RoleRead},
+			{Name: "ops", Key: "admin-key", Role: RoleAdmin},
+		},
+		JWKS:        filepath.Join(dir, "jwks.json"),
+		Issuer:      "sso",
+		ClientCA:    filepath.Join(dir, "ca.pem"),
+		ClientRoles: map[string]string{"uploader": RoleAdmin},
+	}}
+
+	token := func(signer *rsa.PrivateKey, claims jwt.MapClaims) string {
+		tok := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		tok.Header["kid"] = "k1"
+		s, err := tok.SignedString(signer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+	exp := time.Now().Add(time.Hour).Unix()
+	client := func(cn string) *tls.ConnectionState {
+		return &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{{Subject: pkix.Name{CommonName: cn}}}}}
+	}
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		headers  map[string]string
+		tls      *tls.ConnectionState
+		wantCode int
+	}{
+		{name: "OPEN#1", method: http.MethodGet, path: openAPIPath, wantCode: http.StatusOK},
+		{name: "KEY#1", method: http.MethodGet, path: "/api/v1/jobs", headers: map[string]string{"X-API-Key": "read-key"}, wantCode: http.StatusOK},
+		{name: "KEY#2", method: http.MethodGet, path: "/api/v1/jobs", headers: map[string]string{"Authorization": "Bearer read-key"}, wantCode: http.StatusOK},
+		{name: "KEY#3", method: http.MethodPost, path: "/api/v1/files?name=a.tsv", headers: map[string]string{"X-API-Key": "admin-key"}, wantCode: http.StatusAccepted},
+		{name: "JWT#1", method: http.MethodGet, path: "/api/v1/jobs",
+			headers:  map[string]string{"Authorization": "Bearer " + token(key, jwt.MapClaims{"sub": "ann", "iss": "sso", "exp": exp, "role": RoleRead})},
+			wantCode: http.StatusOK},
+		{name: "JWT#2", method: http.MethodPost, path: "/api/v1/files?name=a.tsv",
+			headers:  map[string]string{"Authorization": "Bearer " + token(key, jwt.MapClaims{"sub": "ann", "iss": "sso", "exp": exp, "role": []string{RoleRead, RoleAdmin}})},
+			wantCode: http.StatusAccepted},
+		{name: "MTLS#1", method: http.MethodGet, path: "/api/v1/jobs", tls: client("dashboard"), wantCode: http.StatusOK},
+		{name: "MTLS#2", method: http.MethodPost, path: "/api/v1/files?name=a.tsv", tls: client("uploader"), wantCode: http.StatusAccepted},
+		{name: "BAD#1", method: http.MethodGet, path: "/api/v1/jobs", wantCode: http.StatusUnauthorized},
+		{name: "BAD#2", method: http.MethodGet, path: "/api/v1/jobs", headers: map[string]string{"X-API-Key": "guess"}, wantCode: http.StatusUnauthorized},
+		{name: "BAD#3", method: http.MethodPost, path: "/api/v1/files?name=a.tsv", headers: map[string]string{"X-API-Key": "read-key"}, wantCode: http.StatusForbidden},
+		{name: "BAD#4", method: http.MethodGet, path: "/api/v1/jobs",
+			headers:  map[string]string{"Authorization": "Bearer " + token(key, jwt.MapClaims{"sub": "ann", "iss": "sso", "exp": time.Now().Add(-time.Minute).Unix(), "role": RoleRead})},
+			wantCode: http.StatusUnauthorized},
+		{name: "BAD#5", method: http.MethodGet, path: "/api/v1/jobs",
+			headers:  map[string]string{"Authorization": "Bearer " + token(otherKey, jwt.MapClaims{"sub": "ann", "iss": "sso", "exp": exp, "role": RoleRead})},
+			wantCode: http.StatusUnauthorized},
+		{name: "BAD#6", method: http.MethodGet, path: "/api/v1/jobs",
+			headers:  map[string]string{"Authorization": "Bearer " + token(key, jwt.MapClaims{"sub": "ann", "iss": "other", "exp": exp, "role": RoleRead})},
+			wantCode: http.StatusUnauthorized},
+		{name: "BAD#7", method: http.MethodGet, path: "/api/v1/jobs",
+			headers:  map[string]string{"Authorization": "Bearer " + token(key, jwt.MapClaims{"sub": "ann", "iss": "sso", "exp": exp})},
+			wantCode: http.StatusForbidden},
+		{name: "BAD#8", method: http.MethodPost, path: "/api/v1/files?name=a.tsv", tls: client("dashboard"), wantCode: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := mocks.NewService(t)
+			service.Mock.On("GetJobs", mock.Anything, mock.Anything).Return(shema.Page[shema.Job]{}, nil).Maybe()
+			service.Mock.On("Upload", mock.Anything, "a.tsv", mock.Anything).Return(shema.Job{ID: "8f1d2a0c6b7e4f3a9c5d1e2f3a4b5c6d"}, nil).Maybe()
+			h := NewHandler(service, cnf, zap.NewNop())
+
+			w := httptest.NewRecorder()
+			request := httptest.NewRequest(tt.method, tt.path, strings.NewReader("row"))
+			for k, v := range tt.headers {
+				request.Header.Set(k, v)
+			}
+			request.TLS = tt.tls
+
+			h.engine.ServeHTTP(w, request)
+
+			if w.Code != tt.wantCode {
+				t.Errorf("got %d, want %d: %s", w.Code, tt.wantCode, w.Body.String())
+			}
+			if tt.wantCode == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") == "" {
+				t.Errorf("WWW-Authenticate header is missing")
+			}
+		})
+	}
+}
+
+func TestNewAuth(t *testing.T) {
+	tests := []struct {
+		name        string
+		cnf         config.Auth
+		wantEnabled bool
+		wantErr     bool
+	}{
+		{name: "OK#1", cnf: config.Auth{}, wantEnabled: false},
+		{name: "OK#2", cnf: config.Auth{APIKeys: []config.APIKey{{Name: "ops", Key: "k", Role: RoleAdmin}}}, wantEnabled: true},
+		{name: "BAD#1", cnf: config.Auth{APIKeys: []config.APIKey{{Name: "ops", Key: "k", Role: "root"}}}, wantErr: true},
+		{name: "BAD#2", cnf: config.Auth{JWKS: "missing.json"}, wantErr: true},
+		{name: "BAD#3", cnf: config.Auth{ClientRoles: map[string]string{"uploader": "write"}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := NewAuth(tt.cnf)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got err %v, want err %v", err, tt.wantErr)
+			}
+			if err == nil && a.Enabled() != tt.wantEnabled {
+				t.Errorf("got enabled %v, want %v", a.Enabled(), tt.wantEnabled)
+			}
+		})
+	}
+}
+
+func writeFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
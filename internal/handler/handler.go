@@ -1,159 +1,350 @@
 package handler
 
 import (
-	"bytes"
 	"context"
-	"crypto/rand"
-	"crypto/rsa"
-	"crypto/x509"
-	"crypto/x509/pkix"
-	"encoding/pem"
+	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 	"goTSVParser/config"
+	"goTSVParser/internal/constants"
 	"goTSVParser/internal/domains"
+	"goTSVParser/internal/lifecycle"
 	"goTSVParser/internal/shema"
-	"log"
-	"math/big"
+	"goTSVParser/internal/workers"
+	"io"
+	"mime"
 	"net/http"
-	"os"
-	"time"
+	"strings"
 )
 
 type Handler struct {
 	service domains.Service
 	engine  *gin.Engine
 	config  config.Config
+	auth    *Auth
+	logger  *zap.Logger
 }
 
-func NewHandler(service domains.Service, cnf config.Config) *Handler {
-	router := gin.Default()
+func NewHandler(service domains.Service, cnf config.Config, logger *zap.Logger) *Handler {
+	auth, err := NewAuth(cnf.Auth)
+	if err != nil {
+		logger.Fatal("bad auth config", zap.Error(err))
+	}
+	if !auth.Enabled() {
+		logger.Warn("auth is not configured, api is open")
+	}
+	router := gin.New()
+	// client ip is used by rate limit, X-Forwarded-For of other callers is ignored
+	if err := router.SetTrustedProxies(cnf.Limits.TrustedProxies); err != nil {
+		logger.Fatal("bad limits.trusted_proxies", zap.Error(err))
+	}
 	h := &Handler{
 		service: service,
 		engine:  router,
 		config:  cnf,
+		auth:    auth,
+		logger:  logger,
 	}
 	Route(router, h)
 	return h
 }
 
-func (s *Handler) Start(ctx context.Context) {
-	if s.config.TLS && (s.config.PrivateKey == "" || s.config.Certificate == "") {
-		cert := &x509.Certificate{
-			SerialNumber: big.NewInt(2024),
-			Subject: pkix.Name{
-				Organization: []string{"andogeek"},
-				Country:      []string{"USA"},
-			},
-			NotBefore:             time.Now(),
-			NotAfter:              time.Now().AddDate(10, 0, 0),
-			IsCA:                  true,
-			ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
-			KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
-			BasicConstraintsValid: true,
-		}
+// Start serve api until ctx is done, then wait for in-flight requests up to shutdown timeout
+func (s *Handler) Start(ctx context.Context) error {
+	server := &http.Server{
+		Addr:     s.config.Host,
+		Handler:  s.engine.Handler(),
+		ErrorLog: zap.NewStdLog(s.logger.Named("http")),
+	}
 
-		privateKey, err := rsa.GenerateKey(rand.Reader, 4096)
-		if err != nil {
-			log.Fatal(err)
-		}
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- s.listen(server)
+	}()
 
-		certBytes, err := x509.CreateCertificate(rand.Reader, cert, cert, &privateKey.PublicKey, privateKey)
-		if err != nil {
-			log.Fatal(err)
-		}
+	select {
+	case err := <-errChan:
+		return err
+	case <-ctx.Done():
+	}
 
-		var certPEM bytes.Buffer
-		pem.Encode(&certPEM, &pem.Block{
-			Type:  "CERTIFICATE",
-			Bytes: certBytes,
-		})
-
-		var privateKeyPEM bytes.Buffer
-		pem.Encode(&privateKeyPEM, &pem.Block{
-			Type:  "RSA PRIVATE KEY",
-			Bytes: x509.MarshalPKCS1PrivateKey(privateKey),
-		})
-		cerPemFile, err := os.Create("cerPem.crt")
-		if err != nil {
-			log.Fatalf("Failed to create file: %s", err.Error())
-		}
-		_, err = cerPemFile.Write(certPEM.Bytes())
-		if err != nil {
-			log.Fatalf("Failed to write file: %s", err.Error())
+	// time of drain is shared with other components
+	shutdownCtx, cancel := lifecycle.StopContext(ctx, s.config.ShutdownTimeoutDuration())
+	defer cancel()
+	err := server.Shutdown(shutdownCtx)
+	if err != nil {
+		server.Close()
+		return fmt.Errorf("failed to drain requests: %w", err)
+	}
+	if err := <-errChan; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func (s *Handler) listen(server *http.Server) error {
+	if !s.config.TLS {
+		if s.config.Auth.ClientCA != "" {
+			s.logger.Warn("tls is off, client certificates are not checked")
 		}
+		return server.ListenAndServe()
+	}
+	c, err := tlsConfig(s.config, s.auth, s.logger)
+	if err != nil {
+		return err
+	}
+	server.TLSConfig = c
+	return server.ListenAndServeTLS("", "")
+}
 
-		defer cerPemFile.Close()
+// GetAll get info from db, deprecated alias of GetOccurrences
+func (s *Handler) GetAll(c *gin.Context) {
+	var r shema.Request
+	err := c.ShouldBindJSON(&r)
+	if err != nil {
+		HandlerErr(c, badRequest(err))
+		return
+	}
+	ctx := c.Request.Context()
+	result, err := s.service.GetAll(ctx, r)
+	if err != nil {
+		HandlerErr(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
 
-		privateFile, err := os.Create("private.key")
-		if err != nil {
-			log.Fatalf("Failed to create file: %s", err.Error())
-		}
+}
 
-		_, err = privateFile.Write(privateKeyPEM.Bytes())
-		if err != nil {
-			log.Fatalf("Failed to write file: %s", err.Error())
-		}
+// GetOccurrences get page of unit data, ?page=&limit= or ?cursor=&limit=
+func (s *Handler) GetOccurrences(c *gin.Context) {
+	var r shema.Request
+	err := c.ShouldBindQuery(&r)
+	if err != nil {
+		HandlerErr(c, badRequest(err))
+		return
+	}
+	r.UnitGUID = c.Param("guid")
+	ctx := c.Request.Context()
+	result, err := s.service.GetAll(ctx, r)
+	if err != nil {
+		HandlerErr(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
 
-		defer privateFile.Close()
+var searchParams = map[string]bool{
+	"level_min": true, "level_max": true, "message_id_prefix": true, "q": true,
+	"sort": true, "fields": true, "page": true, "limit": true,
+}
 
-		server := &http.Server{
-			Addr:    s.config.Host,
-			Handler: s.engine.Handler(),
+// Search get data filtered by any field, e.g. ?inventory_id=G-044325&level_min=50&q=температура&sort=-level&fields=unit_guid,text
+func (s *Handler) Search(c *gin.Context) {
+	var r shema.SearchRequest
+	err := c.ShouldBindQuery(&r)
+	if err != nil {
+		HandlerErr(c, badRequest(err))
+		return
+	}
+	r.Filters = make(map[string]string)
+	for key, values := range c.Request.URL.Query() {
+		if !searchParams[key] && len(values) > 0 {
+			r.Filters[key] = values[0]
 		}
+	}
+	ctx := c.Request.Context()
+	result, err := s.service.Search(ctx, r)
+	if err != nil {
+		HandlerErr(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
 
-		go func() {
-			<-ctx.Done()
-			if err := server.Close(); err != nil {
-				log.Fatalf("Server forced to shutdown: %v", err)
-			}
-		}()
-
-		log.Fatal(server.ListenAndServeTLS("cerPem.crt", "private.key"))
-	} else if s.config.TLS && s.config.Certificate != "" && s.config.PrivateKey != "" {
-		server := &http.Server{
-			Addr:    s.config.Host,
-			Handler: s.engine.Handler(),
-		}
+// GetStats count messages by class, level, area, file and unit, optionally of one unit_guid or inventory_id
+func (s *Handler) GetStats(c *gin.Context) {
+	var f shema.StatsFilter
+	err := c.ShouldBindQuery(&f)
+	if err != nil {
+		HandlerErr(c, badRequest(err))
+		return
+	}
+	ctx := c.Request.Context()
+	stats, err := s.service.GetStats(ctx, f)
+	if err != nil {
+		HandlerErr(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
 
-		go func() {
-			<-ctx.Done()
-			if err := server.Close(); err != nil {
-				log.Fatalf("Server forced to shutdown: %v", err)
-			}
-		}()
-
-		log.Fatal(server.ListenAndServeTLS(s.config.Certificate, s.config.PrivateKey))
-	} else {
-		server := &http.Server{
-			Addr:    s.config.Host,
-			Handler: s.engine.Handler(),
-		}
+// GetUnits get known unit guids, ?q= searches by part of guid
+func (s *Handler) GetUnits(c *gin.Context) {
+	s.catalog(c, s.service.GetUnits)
+}
 
-		go func() {
-			<-ctx.Done()
-			if err := server.Close(); err != nil {
-				log.Fatalf("Server forced to shutdown: %v", err)
-			}
-		}()
+// GetInventory get known inventory ids, ?q= searches by part of id
+func (s *Handler) GetInventory(c *gin.Context) {
+	s.catalog(c, s.service.GetInventory)
+}
 
-		log.Fatal(server.ListenAndServe())
+func (s *Handler) catalog(c *gin.Context, get func(context.Context, shema.CatalogFilter) (shema.Page[shema.CatalogItem], error)) {
+	var f shema.CatalogFilter
+	err := c.ShouldBindQuery(&f)
+	if err != nil {
+		HandlerErr(c, badRequest(err))
+		return
+	}
+	ctx := c.Request.Context()
+	page, err := get(ctx, f)
+	if err != nil {
+		HandlerErr(c, err)
+		return
 	}
+	c.JSON(http.StatusOK, page)
 }
 
-// GetAll get info from db
-func (s *Handler) GetAll(c *gin.Context) {
-	var r shema.Request
-	err := c.ShouldBindJSON(&r)
+// GetReport render report of unit on the fly
+func (s *Handler) GetReport(c *gin.Context) {
+	s.report(c, workers.OccurrenceName, c.Param("guid"))
+}
+
+// GetRecordsReport render report of key of schema on the fly
+func (s *Handler) GetRecordsReport(c *gin.Context) {
+	s.report(c, c.Param("schema"), c.Param("key"))
+}
+
+// GetRecords get page of rows of key of schema, ?page=&limit=
+func (s *Handler) GetRecords(c *gin.Context) {
+	var r shema.RecordRequest
+	err := c.ShouldBindQuery(&r)
+	if err != nil {
+		HandlerErr(c, badRequest(err))
+		return
+	}
+	ctx := c.Request.Context()
+	page, err := s.service.GetRecords(ctx, c.Param("schema"), c.Param("key"), r)
 	if err != nil {
 		HandlerErr(c, err)
 		return
 	}
+	c.JSON(http.StatusOK, page)
+}
+
+// GetSchemas list schemas of files
+func (s *Handler) GetSchemas(c *gin.Context) {
+	c.JSON(http.StatusOK, s.service.GetSchemas(c.Request.Context()))
+}
+
+func (s *Handler) report(c *gin.Context, schema, key string) {
+	format, ok := strings.CutPrefix(c.Param("report"), "report.")
+	if !ok {
+		HandlerErr(c, constants.ErrNotFound)
+		return
+	}
 	ctx := c.Request.Context()
-	result, err := s.service.GetAll(ctx, r)
+	report, err := s.service.GetReport(ctx, schema, key, format, c.GetHeader("If-None-Match"))
 	if err != nil {
 		HandlerErr(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, result)
 
+	c.Header("ETag", report.ETag)
+	c.Header("Cache-Control", "private, no-cache")
+	if report.NotModified {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": report.Name}))
+	c.Data(http.StatusOK, report.ContentType, report.Data)
+}
+
+// Upload save input file from multipart form field "file" or from raw body with ?name=
+func (s *Handler) Upload(c *gin.Context) {
+	name, body, err := uploadedFile(c)
+	if err != nil {
+		HandlerErr(c, badRequest(err))
+		return
+	}
+	ctx := c.Request.Context()
+	job, err := s.service.Upload(ctx, name, body)
+	if err != nil {
+		HandlerErr(c, err)
+		return
+	}
+	c.Header("Location", "/api/v1/jobs/"+job.ID)
+	c.JSON(http.StatusAccepted, job)
+}
+
+// GetJob get status of uploaded file
+func (s *Handler) GetJob(c *gin.Context) {
+	ctx := c.Request.Context()
+	job, err := s.service.GetJob(ctx, c.Param("id"))
+	if err != nil {
+		HandlerErr(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+// GetJobs get processed files with status, filtered by status, file and unit_guid
+func (s *Handler) GetJobs(c *gin.Context) {
+	var f shema.JobFilter
+	err := c.ShouldBindQuery(&f)
+	if err != nil {
+		HandlerErr(c, badRequest(err))
+		return
+	}
+	ctx := c.Request.Context()
+	jobs, err := s.service.GetJobs(ctx, f)
+	if err != nil {
+		HandlerErr(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, jobs)
+}
+
+// Healthz liveness probe, 503 when watcher is stalled
+func (s *Handler) Healthz(c *gin.Context) {
+	probe(c, s.service.Liveness(c.Request.Context()))
+}
+
+// Readyz readiness probe, 503 when watcher is stalled or db is unreachable
+func (s *Handler) Readyz(c *gin.Context) {
+	probe(c, s.service.Readiness(c.Request.Context()))
+}
+
+func probe(c *gin.Context, h shema.Health) {
+	code := http.StatusOK
+	if h.Status != shema.HealthOK {
+		code = http.StatusServiceUnavailable
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(code, h)
+}
+
+// uploadedFile stream file from request without buffering it in memory
+func uploadedFile(c *gin.Context) (string, io.Reader, error) {
+	if c.ContentType() != "multipart/form-data" {
+		return c.Query("name"), c.Request.Body, nil
+	}
+
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		return "", nil, err
+	}
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return "", nil, http.ErrMissingFile
+		}
+		if err != nil {
+			return "", nil, err
+		}
+		if part.FormName() == "file" {
+			return part.FileName(), part, nil
+		}
+	}
 }
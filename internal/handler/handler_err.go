@@ -1,28 +1,66 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"goTSVParser/internal/constants"
+	"goTSVParser/internal/logging"
+	"goTSVParser/internal/shema"
 	"net/http"
 )
 
 var UnmarshalTypeError *json.UnmarshalTypeError
 
+// HandlerErr answer with json error body, status depends on type of err
 func HandlerErr(c *gin.Context, err error) {
-	if err != nil {
-		switch {
-		case errors.As(err, &UnmarshalTypeError):
-			err := fmt.Sprintf("bad json %s", err)
-			c.JSON(http.StatusBadRequest, err)
-		default:
-			c.JSON(http.StatusBadRequest, err)
-		}
+	if err == nil {
+		c.Status(http.StatusOK)
 		return
+	}
 
+	var validationErr *constants.ValidationError
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &maxBytesErr):
+		abortErr(c, http.StatusRequestEntityTooLarge, "too_large", fmt.Sprintf("body is larger than %d bytes", maxBytesErr.Limit), nil)
+	case errors.As(err, &UnmarshalTypeError), errors.Is(err, constants.ErrBadRequest), errors.Is(err, constants.ErrUnsupportedFormat):
+		abortErr(c, http.StatusBadRequest, "bad_request", err.Error(), nil)
+	case errors.As(err, &validationErr):
+		abortErr(c, http.StatusUnprocessableEntity, "invalid_request", err.Error(), []*constants.ValidationError{validationErr})
+	case errors.Is(err, constants.ErrInvalidRequest):
+		abortErr(c, http.StatusUnprocessableEntity, "invalid_request", err.Error(), nil)
+	case errors.Is(err, constants.ErrNotTSV), errors.Is(err, constants.ErrInvalidTSV):
+		abortErr(c, http.StatusUnprocessableEntity, "invalid_file", err.Error(), nil)
+	case errors.Is(err, constants.ErrUnauthorized):
+		c.Header("WWW-Authenticate", `Bearer realm="api"`)
+		abortErr(c, http.StatusUnauthorized, "unauthorized", err.Error(), nil)
+	case errors.Is(err, constants.ErrForbidden):
+		abortErr(c, http.StatusForbidden, "forbidden", err.Error(), nil)
+	case errors.Is(err, constants.ErrNotFound):
+		abortErr(c, http.StatusNotFound, "not_found", err.Error(), nil)
+	case errors.Is(err, constants.ErrRateLimited):
+		abortErr(c, http.StatusTooManyRequests, "rate_limited", err.Error(), nil)
+	case errors.Is(err, context.DeadlineExceeded):
+		abortErr(c, http.StatusGatewayTimeout, "timeout", "request timed out", nil)
+	case errors.Is(err, constants.ErrUnavailable):
+		logging.From(c.Request.Context(), zap.L()).Error("service unavailable", zap.Error(err))
+		abortErr(c, http.StatusServiceUnavailable, "unavailable", constants.ErrUnavailable.Error(), nil)
+	default:
+		// details of internal errors are only logged
+		logging.From(c.Request.Context(), zap.L()).Error("internal error", zap.Error(err))
+		abortErr(c, http.StatusInternalServerError, "internal", "internal server error", nil)
 	}
+}
+
+func abortErr(c *gin.Context, status int, code string, message string, details interface{}) {
+	c.AbortWithStatusJSON(status, shema.Error{Code: code, Message: message, Details: details})
+}
 
-	c.Status(http.StatusOK)
-	return
+// badRequest mark error of binding request, e.g. malformed json or not a number in query
+func badRequest(err error) error {
+	return fmt.Errorf("%w: %w", constants.ErrBadRequest, err)
 }
@@ -7,11 +7,15 @@ import (
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
 	"goTSVParser/config"
+	"goTSVParser/internal/constants"
 	"goTSVParser/internal/domains/mocks"
 	"goTSVParser/internal/shema"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -24,7 +28,7 @@ func TestHandler_GetAll(t *testing.T) {
 		body        shema.Request
 		serviceMock serviceMock
 		wantCode    int
-		want        [][]shema.Tsv
+		want        shema.Page[shema.Tsv]
 	}{
 
 		{
@@ -35,23 +39,23 @@ func TestHandler_GetAll(t *testing.T) {
 				Page:     2,
 			},
 			serviceMock: func(c *mocks.Service) {
-				c.Mock.On("GetAll", mock.Anything, shema.Request{UnitGUID: "ajsuiwp18203475nmgbdxgsk", Limit: 1, Page: 2}).Return([][]shema.Tsv{{shema.Tsv{UnitGUID: "ajsuiwp18203475nmgbdxgsk"}}, {shema.Tsv{UnitGUID: "ajsuiwp18203475nmgbdxgsk"}}}, nil).Times(1)
+				c.Mock.On("GetAll", mock.Anything, shema.Request{UnitGUID: "ajsuiwp18203475nmgbdxgsk", Limit: 1, Page: 2}).Return(shema.Page[shema.Tsv]{Items: []shema.Tsv{{ID: 2, UnitGUID: "ajsuiwp18203475nmgbdxgsk"}}, Total: 3, Page: 2, PageSize: 1, NextCursor: "Mg"}, nil).Times(1)
 			},
 			wantCode: http.StatusOK,
-			want:     [][]shema.Tsv{{shema.Tsv{UnitGUID: "ajsuiwp18203475nmgbdxgsk"}}, {shema.Tsv{UnitGUID: "ajsuiwp18203475nmgbdxgsk"}}},
+			want:     shema.Page[shema.Tsv]{Items: []shema.Tsv{{ID: 2, UnitGUID: "ajsuiwp18203475nmgbdxgsk"}}, Total: 3, Page: 2, PageSize: 1, NextCursor: "Mg"},
 		},
 		{
 			name: "OK#2",
 			body: shema.Request{
 				UnitGUID: "ajsuiwp18203475nmgbdxgsk",
 				Limit:    1,
-				Page:     3,
+				Cursor:   "Mg",
 			},
 			serviceMock: func(c *mocks.Service) {
-				c.Mock.On("GetAll", mock.Anything, shema.Request{UnitGUID: "ajsuiwp18203475nmgbdxgsk", Limit: 1, Page: 3}).Return([][]shema.Tsv{{shema.Tsv{UnitGUID: "ajsuiwp18203475nmgbdxgsk"}}}, nil).Times(1)
+				c.Mock.On("GetAll", mock.Anything, shema.Request{UnitGUID: "ajsuiwp18203475nmgbdxgsk", Limit: 1, Cursor: "Mg"}).Return(shema.Page[shema.Tsv]{Items: []shema.Tsv{{ID: 3, UnitGUID: "ajsuiwp18203475nmgbdxgsk"}}, Total: 3, PageSize: 1}, nil).Times(1)
 			},
 			wantCode: http.StatusOK,
-			want:     [][]shema.Tsv{{shema.Tsv{UnitGUID: "ajsuiwp18203475nmgbdxgsk"}}},
+			want:     shema.Page[shema.Tsv]{Items: []shema.Tsv{{ID: 3, UnitGUID: "ajsuiwp18203475nmgbdxgsk"}}, Total: 3, PageSize: 1},
 		},
 		{
 			name: "BAD#1",
@@ -61,10 +65,9 @@ func TestHandler_GetAll(t *testing.T) {
 				Page:     2,
 			},
 			serviceMock: func(c *mocks.Service) {
-				c.Mock.On("GetAll", mock.Anything, shema.Request{UnitGUID: "", Limit: 1, Page: 2}).Return(nil, errors.New("invalid data")).Times(1)
+				c.Mock.On("GetAll", mock.Anything, shema.Request{UnitGUID: "", Limit: 1, Page: 2}).Return(shema.Page[shema.Tsv]{}, constants.ErrInvalidRequest).Times(1)
 			},
-			wantCode: http.StatusBadRequest,
-			want:     nil,
+			wantCode: http.StatusUnprocessableEntity,
 		},
 		{
 			name: "BAD#2",
@@ -74,22 +77,19 @@ func TestHandler_GetAll(t *testing.T) {
 				Page:     1,
 			},
 			serviceMock: func(c *mocks.Service) {
-				c.Mock.On("GetAll", mock.Anything, shema.Request{UnitGUID: "1yua683", Limit: 1, Page: 1}).Return(nil, errors.New("not found")).Times(1)
+				c.Mock.On("GetAll", mock.Anything, shema.Request{UnitGUID: "1yua683", Limit: 1, Page: 1}).Return(shema.Page[shema.Tsv]{}, constants.ErrNotFound).Times(1)
 			},
-			wantCode: http.StatusBadRequest,
-			want:     nil,
+			wantCode: http.StatusNotFound,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			g := gin.Default()
 			service := mocks.NewService(t)
-			h := NewHandler(service, config.Config{})
+			h := NewHandler(service, config.Config{}, zap.NewNop())
 			tt.serviceMock(service)
 
 			path := "/api/all"
-			g.POST(path, h.GetAll)
 			b, err := json.Marshal(tt.body)
 			if err != nil {
 				fmt.Errorf("failed json")
@@ -98,26 +98,618 @@ func TestHandler_GetAll(t *testing.T) {
 			w := httptest.NewRecorder()
 			request := httptest.NewRequest(http.MethodPost, path, strings.NewReader(string(b)))
 
-			g.ServeHTTP(w, request)
+			h.engine.ServeHTTP(w, request)
 
 			if w.Code != tt.wantCode {
 				t.Errorf("got %d, want %d", w.Code, tt.wantCode)
 			}
-
-			wantResponse, err := json.Marshal(tt.want)
-			if err != nil {
-				fmt.Errorf("failed json")
+			if w.Header().Get("Deprecation") != "true" {
+				t.Errorf("got deprecation %q, want true", w.Header().Get("Deprecation"))
+			}
+			if tt.wantCode != http.StatusOK {
 				return
 			}
 
-			response, err := json.Marshal(w.Body)
+			var got shema.Page[shema.Tsv]
+			err = json.Unmarshal(w.Body.Bytes(), &got)
 			if err != nil {
 				fmt.Errorf("failed json")
 				return
 			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandler_GetReport(t *testing.T) {
+	report := shema.Report{
+		Name:        "01749246-9617-585e-9e19-157ccad61ee2.html",
+		ContentType: "text/html; charset=utf-8",
+		ETag:        `"abc"`,
+		Data:        []byte("<html></html>"),
+	}
+	tests := []struct {
+		name            string
+		path            string
+		ifNoneMatch     string
+		serviceMock     serviceMock
+		wantCode        int
+		wantContentType string
+		wantBody        string
+	}{
+		{
+			name: "OK#1",
+			path: "/api/v1/units/01749246-9617-585e-9e19-157ccad61ee2/report.html",
+			serviceMock: func(c *mocks.Service) {
+				c.Mock.On("GetReport", mock.Anything, "occurrence", "01749246-9617-585e-9e19-157ccad61ee2", "html", "").Return(report, nil).Times(1)
+			},
+			wantCode:        http.StatusOK,
+			wantContentType: "text/html; charset=utf-8",
+			wantBody:        "<html></html>",
+		},
+		{
+			name:        "NOT_MODIFIED#1",
+			path:        "/api/v1/units/01749246-9617-585e-9e19-157ccad61ee2/report.html",
+			ifNoneMatch: `"abc"`,
+			serviceMock: func(c *mocks.Service) {
+				c.Mock.On("GetReport", mock.Anything, "occurrence", "01749246-9617-585e-9e19-157ccad61ee2", "html", `"abc"`).
+					Return(shema.Report{ETag: `"abc"`, NotModified: true}, nil).Times(1)
+			},
+			wantCode: http.StatusNotModified,
+		},
+		{
+			name: "BAD#1",
+			path: "/api/v1/units/1yua683/report.pdf",
+			serviceMock: func(c *mocks.Service) {
+				c.Mock.On("GetReport", mock.Anything, "occurrence", "1yua683", "pdf", "").Return(shema.Report{}, constants.ErrNotFound).Times(1)
+			},
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name: "BAD#2",
+			path: "/api/v1/units/1yua683/report.doc",
+			serviceMock: func(c *mocks.Service) {
+				c.Mock.On("GetReport", mock.Anything, "occurrence", "1yua683", "doc", "").Return(shema.Report{}, constants.ErrUnsupportedFormat).Times(1)
+			},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:        "BAD#3",
+			path:        "/api/v1/units/1yua683/summary.pdf",
+			serviceMock: func(c *mocks.Service) {},
+			wantCode:    http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := mocks.NewService(t)
+			h := NewHandler(service, config.Config{}, zap.NewNop())
+			tt.serviceMock(service)
+
+			w := httptest.NewRecorder()
+			request := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.ifNoneMatch != "" {
+				request.Header.Set("If-None-Match", tt.ifNoneMatch)
+			}
+
+			h.engine.ServeHTTP(w, request)
+
+			if w.Code != tt.wantCode {
+				t.Errorf("got %d, want %d", w.Code, tt.wantCode)
+			}
+			if tt.wantContentType != "" && w.Header().Get("Content-Type") != tt.wantContentType {
+				t.Errorf("got content type %s, want %s", w.Header().Get("Content-Type"), tt.wantContentType)
+			}
+			if tt.wantBody != "" && w.Body.String() != tt.wantBody {
+				t.Errorf("got %s, want %s", w.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestHandler_Upload(t *testing.T) {
+	multipartBody := func(field, name, content string) (string, *bytes.Buffer) {
+		var b bytes.Buffer
+		w := multipart.NewWriter(&b)
+		part, _ := w.CreateFormFile(field, name)
+		part.Write([]byte(content))
+		w.Close()
+		return w.FormDataContentType(), &b
+	}
+	job := shema.Job{ID: "8f1d2a0c6b7e4f3a9c5d1e2f3a4b5c6d", File: "from/uploads/8f1d2a0c6b7e4f3a9c5d1e2f3a4b5c6d/a.tsv", Status: shema.JobPending}
+	tests := []struct {
+		name        string
+		body        func() (string, *bytes.Buffer)
+		query       string
+		serviceMock serviceMock
+		wantCode    int
+	}{
+		{
+			name: "OK#1",
+			body: func() (string, *bytes.Buffer) {
+				return multipartBody("file", "a.tsv", "row")
+			},
+			serviceMock: func(c *mocks.Service) {
+				c.Mock.On("Upload", mock.Anything, "a.tsv", mock.Anything).Return(job, nil).Times(1)
+			},
+			wantCode: http.StatusAccepted,
+		},
+		{
+			name: "OK#2",
+			body: func() (string, *bytes.Buffer) {
+				return "text/tab-separated-values", bytes.NewBufferString("row")
+			},
+			query: "?name=a.tsv",
+			serviceMock: func(c *mocks.Service) {
+				c.Mock.On("Upload", mock.Anything, "a.tsv", mock.Anything).Return(job, nil).Times(1)
+			},
+			wantCode: http.StatusAccepted,
+		},
+		{
+			name: "BAD#1",
+			body: func() (string, *bytes.Buffer) {
+				return multipartBody("document", "a.tsv", "row")
+			},
+			serviceMock: func(c *mocks.Service) {},
+			wantCode:    http.StatusBadRequest,
+		},
+		{
+			name: "BAD#2",
+			body: func() (string, *bytes.Buffer) {
+				return multipartBody("file", "a.csv", "row")
+			},
+			serviceMock: func(c *mocks.Service) {
+				c.Mock.On("Upload", mock.Anything, "a.csv", mock.Anything).Return(shema.Job{}, constants.ErrNotTSV).Times(1)
+			},
+			wantCode: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := mocks.NewService(t)
+			h := NewHandler(service, config.Config{}, zap.NewNop())
+			tt.serviceMock(service)
+
+			contentType, body := tt.body()
+			w := httptest.NewRecorder()
+			request := httptest.NewRequest(http.MethodPost, "/api/v1/files"+tt.query, body)
+			request.Header.Set("Content-Type", contentType)
+
+			h.engine.ServeHTTP(w, request)
+
+			if w.Code != tt.wantCode {
+				t.Errorf("got %d, want %d", w.Code, tt.wantCode)
+			}
+			if tt.wantCode == http.StatusAccepted && w.Header().Get("Location") != "/api/v1/jobs/"+job.ID {
+				t.Errorf("got location %s, want %s", w.Header().Get("Location"), "/api/v1/jobs/"+job.ID)
+			}
+		})
+	}
+}
+
+func TestHandler_Search(t *testing.T) {
+	level := 50
+	tests := []struct {
+		name        string
+		query       string
+		serviceMock serviceMock
+		wantCode    int
+	}{
+		{
+			name:  "OK#1",
+			query: "?inventory_id=G-044325&area=LOCAL&level_min=50&q=%D1%80%D0%B0%D0%B7%D0%BC%D0%BE%D1%80%D0%BE%D0%B7%D0%BA%D0%B0&sort=-level&fields=unit_guid&page=2&limit=10",
+			serviceMock: func(c *mocks.Service) {
+				c.Mock.On("Search", mock.Anything, shema.SearchRequest{
+					Filters:  map[string]string{"inventory_id": "G-044325", "area": "LOCAL"},
+					LevelMin: &level,
+					Query:    "разморозка",
+					Sort:     "-level",
+					Fields:   "unit_guid",
+					Page:     2,
+					Limit:    10,
+				}).Return(shema.Page[map[string]interface{}]{}, nil).Times(1)
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:        "BAD#1",
+			query:       "?level_min=high",
+			serviceMock: func(c *mocks.Service) {},
+			wantCode:    http.StatusBadRequest,
+		},
+		{
+			name:  "BAD#2",
+			query: "?colour=red",
+			serviceMock: func(c *mocks.Service) {
+				c.Mock.On("Search", mock.Anything, mock.Anything).Return(shema.Page[map[string]interface{}]{}, &constants.ValidationError{Field: "colour", Reason: "is unknown filter"}).Times(1)
+			},
+			wantCode: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := mocks.NewService(t)
+			h := NewHandler(service, config.Config{}, zap.NewNop())
+			tt.serviceMock(service)
+
+			w := httptest.NewRecorder()
+			request := httptest.NewRequest(http.MethodGet, "/api/v1/search"+tt.query, nil)
+
+			h.engine.ServeHTTP(w, request)
+
+			if w.Code != tt.wantCode {
+				t.Errorf("got %d, want %d", w.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestHandler_Catalog(t *testing.T) {
+	tests := []struct {
+		name        string
+		path        string
+		serviceMock serviceMock
+		wantCode    int
+	}{
+		{
+			name: "OK#1",
+			path: "/api/v1/units?q=0174&page=2&limit=5",
+			serviceMock: func(c *mocks.Service) {
+				c.Mock.On("GetUnits", mock.Anything, shema.CatalogFilter{Query: "0174", Page: 2, Limit: 5}).
+					Return(shema.Page[shema.CatalogItem]{}, nil).Times(1)
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name: "OK#2",
+			path: "/api/v1/inventory?q=G-04",
+			serviceMock: func(c *mocks.Service) {
+				c.Mock.On("GetInventory", mock.Anything, shema.CatalogFilter{Query: "G-04"}).
+					Return(shema.Page[shema.CatalogItem]{}, nil).Times(1)
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:        "BAD#1",
+			path:        "/api/v1/units?page=first",
+			serviceMock: func(c *mocks.Service) {},
+			wantCode:    http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := mocks.NewService(t)
+			h := NewHandler(service, config.Config{}, zap.NewNop())
+			tt.serviceMock(service)
+
+			w := httptest.NewRecorder()
+			request := httptest.NewRequest(http.MethodGet, tt.path, nil)
+
+			h.engine.ServeHTTP(w, request)
+
+			if w.Code != tt.wantCode {
+				t.Errorf("got %d, want %d", w.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestHandler_Records(t *testing.T) {
+	tests := []struct {
+		name        string
+		path        string
+		serviceMock serviceMock
+		wantCode    int
+	}{
+		{
+			name: "OK#1",
+			path: "/api/v1/schemas",
+			serviceMock: func(c *mocks.Service) {
+				c.Mock.On("GetSchemas", mock.Anything).Return([]shema.SchemaInfo{{Name: "alarms", Key: "device"}}).Times(1)
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name: "OK#2",
+			path: "/api/v1/schemas/alarms/keys/dev-1/rows?page=2&limit=5",
+			serviceMock: func(c *mocks.Service) {
+				c.Mock.On("GetRecords", mock.Anything, "alarms", "dev-1", shema.RecordRequest{Page: 2, Limit: 5}).
+					Return(shema.Page[map[string]interface{}]{}, nil).Times(1)
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name: "OK#3",
+			path: "/api/v1/schemas/alarms/keys/dev-1/report.html",
+			serviceMock: func(c *mocks.Service) {
+				c.Mock.On("GetReport", mock.Anything, "alarms", "dev-1", "html", "").
+					Return(shema.Report{ContentType: "text/html; charset=utf-8"}, nil).Times(1)
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:        "BAD#1",
+			path:        "/api/v1/schemas/alarms/keys/dev-1/rows?page=first",
+			serviceMock: func(c *mocks.Service) {},
+			wantCode:    http.StatusBadRequest,
+		},
+		{
+			name: "BAD#2",
+			path: "/api/v1/schemas/unknown/keys/dev-1/rows",
+			serviceMock: func(c *mocks.Service) {
+				c.Mock.On("GetRecords", mock.Anything, "unknown", "dev-1", shema.RecordRequest{}).
+					Return(shema.Page[map[string]interface{}]{}, fmt.Errorf("%w: unknown schema", constants.ErrBadRequest)).Times(1)
+			},
+			wantCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := mocks.NewService(t)
+			h := NewHandler(service, config.Config{}, zap.NewNop())
+			tt.serviceMock(service)
+
+			w := httptest.NewRecorder()
+			h.engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if w.Code != tt.wantCode {
+				t.Errorf("got %d, want %d: %s", w.Code, tt.wantCode, w.Body.String())
+			}
+		})
+	}
+}
 
-			if bytes.Equal(wantResponse, response) {
-				t.Errorf("got %s, want %s", w.Body, tt.want)
+func TestHandler_GetOccurrences(t *testing.T) {
+	tests := []struct {
+		name        string
+		path        string
+		serviceMock serviceMock
+		wantCode    int
+	}{
+		{
+			name: "OK#1",
+			path: "/api/v1/units/ajsuiwp18203475nmgbdxgsk/occurrences?page=2&limit=1",
+			serviceMock: func(c *mocks.Service) {
+				c.Mock.On("GetAll", mock.Anything, shema.Request{UnitGUID: "ajsuiwp18203475nmgbdxgsk", Limit: 1, Page: 2}).
+					Return(shema.Page[shema.Tsv]{}, nil).Times(1)
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name: "OK#2",
+			path: "/api/v1/units/ajsuiwp18203475nmgbdxgsk/occurrences?cursor=Mg",
+			serviceMock: func(c *mocks.Service) {
+				c.Mock.On("GetAll", mock.Anything, shema.Request{UnitGUID: "ajsuiwp18203475nmgbdxgsk", Cursor: "Mg"}).
+					Return(shema.Page[shema.Tsv]{}, nil).Times(1)
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:        "BAD#1",
+			path:        "/api/v1/units/ajsuiwp18203475nmgbdxgsk/occurrences?limit=ten",
+			serviceMock: func(c *mocks.Service) {},
+			wantCode:    http.StatusBadRequest,
+		},
+		{
+			name:        "BAD#2",
+			path:        "/api/v2/units/ajsuiwp18203475nmgbdxgsk/occurrences",
+			serviceMock: func(c *mocks.Service) {},
+			wantCode:    http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := mocks.NewService(t)
+			h := NewHandler(service, config.Config{}, zap.NewNop())
+			tt.serviceMock(service)
+
+			w := httptest.NewRecorder()
+			request := httptest.NewRequest(http.MethodGet, tt.path, nil)
+
+			h.engine.ServeHTTP(w, request)
+
+			if w.Code != tt.wantCode {
+				t.Errorf("got %d, want %d", w.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestHandler_Probes(t *testing.T) {
+	ok := shema.Health{Status: shema.HealthOK, Watcher: shema.WatcherHealth{Status: shema.HealthOK}}
+	dbDown := shema.Health{Status: shema.HealthFail, DB: &shema.DBHealth{Status: shema.HealthFail, Error: "connection refused"},
+		Watcher: shema.WatcherHealth{Status: shema.HealthOK}}
+	tests := []struct {
+		name        string
+		path        string
+		serviceMock serviceMock
+		wantCode    int
+		want        shema.Health
+	}{
+		{
+			name: "OK#1",
+			path: "/healthz",
+			serviceMock: func(c *mocks.Service) {
+				c.Mock.On("Liveness", mock.Anything).Return(ok).Times(1)
+			},
+			wantCode: http.StatusOK,
+			want:     ok,
+		},
+		{
+			name: "OK#2",
+			path: "/readyz",
+			serviceMock: func(c *mocks.Service) {
+				c.Mock.On("Readiness", mock.Anything).Return(ok).Times(1)
+			},
+			wantCode: http.StatusOK,
+			want:     ok,
+		},
+		{
+			name: "BAD#1",
+			path: "/readyz",
+			serviceMock: func(c *mocks.Service) {
+				c.Mock.On("Readiness", mock.Anything).Return(dbDown).Times(1)
+			},
+			wantCode: http.StatusServiceUnavailable,
+			want:     dbDown,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := mocks.NewService(t)
+			// probes are open when api needs auth
+			h := NewHandler(service, config.Config{Auth: config.Auth{APIKeys: []config.APIKey{{Name: "ops", Key: "secret", Role: RoleAdmin}}}}, zap.NewNop())
+			tt.serviceMock(service)
+
+			w := httptest.NewRecorder()
+			request := httptest.NewRequest(http.MethodGet, tt.path, nil)
+
+			h.engine.ServeHTTP(w, request)
+
+			if w.Code != tt.wantCode {
+				t.Errorf("got %d, want %d", w.Code, tt.wantCode)
+			}
+			var got shema.Health
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandler_Legacy(t *testing.T) {
+	tests := []struct {
+		name          string
+		path          string
+		serviceMock   serviceMock
+		wantCode      int
+		wantSuccessor string
+	}{
+		{
+			name: "OK#1",
+			path: "/api/units?q=0174",
+			serviceMock: func(c *mocks.Service) {
+				c.Mock.On("GetUnits", mock.Anything, shema.CatalogFilter{Query: "0174"}).
+					Return(shema.Page[shema.CatalogItem]{}, nil).Times(1)
+			},
+			wantCode:      http.StatusOK,
+			wantSuccessor: "/api/v1/units",
+		},
+		{
+			name: "OK#2",
+			path: "/api/inventory",
+			serviceMock: func(c *mocks.Service) {
+				c.Mock.On("GetInventory", mock.Anything, shema.CatalogFilter{}).
+					Return(shema.Page[shema.CatalogItem]{}, nil).Times(1)
+			},
+			wantCode:      http.StatusOK,
+			wantSuccessor: "/api/v1/inventory",
+		},
+		{
+			name: "OK#3",
+			path: "/api/units/01749246-9617-585e-9e19-157ccad61ee2/report.html",
+			serviceMock: func(c *mocks.Service) {
+				c.Mock.On("GetReport", mock.Anything, "occurrence", "01749246-9617-585e-9e19-157ccad61ee2", "html", "").
+					Return(shema.Report{ContentType: "text/html; charset=utf-8"}, nil).Times(1)
+			},
+			wantCode:      http.StatusOK,
+			wantSuccessor: "/api/v1/units/{guid}/{report}",
+		},
+		{
+			name: "BAD#1",
+			path: "/api/units/1yua683/report.doc",
+			serviceMock: func(c *mocks.Service) {
+				c.Mock.On("GetReport", mock.Anything, "occurrence", "1yua683", "doc", "").Return(shema.Report{}, constants.ErrUnsupportedFormat).Times(1)
+			},
+			wantCode:      http.StatusBadRequest,
+			wantSuccessor: "/api/v1/units/{guid}/{report}",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := mocks.NewService(t)
+			h := NewHandler(service, config.Config{}, zap.NewNop())
+			tt.serviceMock(service)
+
+			w := httptest.NewRecorder()
+			h.engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if w.Code != tt.wantCode {
+				t.Errorf("got %d, want %d", w.Code, tt.wantCode)
+			}
+			wantLink := "<" + tt.wantSuccessor + `>; rel="successor-version"`
+			if w.Header().Get("Deprecation") != "true" || w.Header().Get("Link") != wantLink {
+				t.Errorf("got Deprecation %q and Link %q, want %q", w.Header().Get("Deprecation"), w.Header().Get("Link"), wantLink)
+			}
+		})
+	}
+}
+
+func TestHandlerErr(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantCode int
+		want     shema.Error
+	}{
+		{
+			name:     "INVALID#1",
+			err:      &constants.ValidationError{Field: "limit", Reason: "must be in 1..100"},
+			wantCode: http.StatusUnprocessableEntity,
+			want: shema.Error{Code: "invalid_request", Message: "invalid request: limit must be in 1..100",
+				Details: []interface{}{map[string]interface{}{"field": "limit", "reason": "must be in 1..100"}}},
+		},
+		{
+			name:     "NOT_FOUND#1",
+			err:      constants.ErrNotFound,
+			wantCode: http.StatusNotFound,
+			want:     shema.Error{Code: "not_found", Message: "not found"},
+		},
+		{
+			name:     "UNAVAILABLE#1",
+			err:      fmt.Errorf("failed to count rows: %w", fmt.Errorf("%w: dial tcp: connection refused", constants.ErrUnavailable)),
+			wantCode: http.StatusServiceUnavailable,
+			want:     shema.Error{Code: "unavailable", Message: "service unavailable"},
+		},
+		{
+			name:     "INTERNAL#1",
+			err:      errors.New("pq: relation \"occurrence\" does not exist"),
+			wantCode: http.StatusInternalServerError,
+			want:     shema.Error{Code: "internal", Message: "internal server error"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/stats", nil)
+
+			HandlerErr(c, tt.err)
+
+			if w.Code != tt.wantCode {
+				t.Errorf("got %d, want %d", w.Code, tt.wantCode)
+			}
+			var got shema.Error
+			err := json.Unmarshal(w.Body.Bytes(), &got)
+			if err != nil {
+				t.Fatalf("failed json: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
 			}
 		})
 	}
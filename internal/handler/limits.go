@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"goTSVParser/internal/constants"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// buckets are dropped when there are more idle clients than this
+const maxBuckets = 10000
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// rateLimiter is token bucket per client
+type rateLimiter struct {
+	mu      sync.Mutex
+	rate    float64
+	burst   float64
+	buckets map[string]*bucket
+	now     func() time.Time
+}
+
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	if burst < 1 {
+		burst = int(math.Max(1, math.Ceil(rate)))
+	}
+	return &rateLimiter{rate: rate, burst: float64(burst), buckets: make(map[string]*bucket), now: time.Now}
+}
+
+// allow take token of client, or return how long to wait for next one
+func (l *rateLimiter) allow(client string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b := l.refill(client)
+	if b.tokens < 1 {
+		return false, l.wait(b)
+	}
+	b.tokens--
+	return true, 0
+}
+
+// blocked tell if client has no tokens without taking one
+func (l *rateLimiter) blocked(client string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b := l.refill(client)
+	return b.tokens < 1, l.wait(b)
+}
+
+// refill bucket of client for time since its last use
+func (l *rateLimiter) refill(client string) *bucket {
+	now := l.now()
+	b, ok := l.buckets[client]
+	if !ok {
+		if len(l.buckets) >= maxBuckets {
+			l.sweep(now)
+		}
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[client] = b
+	}
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+	return b
+}
+
+func (l *rateLimiter) wait(b *bucket) time.Duration {
+	if b.tokens >= 1 {
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+}
+
+// sweep drop buckets which are full again, they are the same as new ones
+func (l *rateLimiter) sweep(now time.Time) {
+	for client, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.burst {
+			delete(l.buckets, client)
+		}
+	}
+}
+
+// rateLimit limit requests per authenticated caller, or per ip when auth is off
+func (l *rateLimiter) rateLimit(c *gin.Context) {
+	client := "ip:" + c.ClientIP()
+	if p := c.GetString("principal"); p != "" {
+		client = "principal:" + p
+	}
+	if ok, wait := l.allow(client); !ok {
+		tooMany(c, wait)
+		return
+	}
+	c.Next()
+}
+
+// authFailures limit failed authentication per ip, it runs before auth so credentials can't be guessed faster
+func (l *rateLimiter) authFailures(c *gin.Context) {
+	client := "ip:" + c.ClientIP()
+	if blocked, wait := l.blocked(client); blocked {
+		tooMany(c, wait)
+		return
+	}
+	c.Next()
+	if s := c.Writer.Status(); s == http.StatusUnauthorized || s == http.StatusForbidden {
+		l.allow(client)
+	}
+}
+
+func tooMany(c *gin.Context, wait time.Duration) {
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	HandlerErr(c, constants.ErrRateLimited)
+}
+
+// bodyLimit fail reading of body bigger than n bytes
+func bodyLimit(n int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.ContentLength > n {
+			HandlerErr(c, &http.MaxBytesError{Limit: n})
+			return
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, n)
+		c.Next()
+	}
+}
+
+// timeout cancel context of request, db queries stop when it's done
+func timeout(d time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), d)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// limits of route from config
+func (s *Handler) limits(r route) []gin.HandlerFunc {
+	cfg := s.config.Limits
+	var handlers []gin.HandlerFunc
+
+	maxBody, seconds := cfg.MaxBodyBytes, cfg.RequestTimeout
+	if r.upload {
+		maxBody, seconds = cfg.MaxUploadBytes, cfg.UploadTimeout
+	}
+	if maxBody > 0 {
+		handlers = append(handlers, bodyLimit(maxBody))
+	}
+	if seconds > 0 {
+		handlers = append(handlers, timeout(time.Duration(seconds)*time.Second))
+	}
+	return handlers
+}
+
+// rateLimiters of authenticated requests and of failed authentication
+func (s *Handler) rateLimiters() (gin.HandlerFunc, gin.HandlerFunc) {
+	cfg := s.config.Limits
+	if cfg.RatePerSecond <= 0 {
+		next := func(c *gin.Context) { c.Next() }
+		return next, next
+	}
+	return newRateLimiter(cfg.RatePerSecond, cfg.Burst).rateLimit, newRateLimiter(cfg.RatePerSecond, cfg.Burst).authFailures
+}
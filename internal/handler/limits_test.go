@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+	"goTSVParser/config"
+	"goTSVParser/internal/domains/mocks"
+	"goTSVParser/internal/shema"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter_Allow(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := newRateLimiter(2, 2)
+	l.now = func() time.Time { return now }
+
+	steps := []struct {
+		after    time.Duration
+		client   string
+		want     bool
+		wantWait time.Duration
+	}{
+		{client: "a", want: true},
+		{client: "a", want: true},
+		{client: "a", want: false, wantWait: 500 * time.Millisecond},
+		{client: "b", want: true},
+		{after: 250 * time.Millisecond, client: "a", want: false, wantWait: 250 * time.Millisecond},
+		{after: 250 * time.Millisecond, client: "a", want: true},
+		{after: time.Hour, client: "a", want: true},
+		{client: "a", want: true},
+		{client: "a", want: false, wantWait: 500 * time.Millisecond},
+	}
+	for i, s := range steps {
+		now = now.Add(s.after)
+		got, wait := l.allow(s.client)
+		if got != s.want || wait != s.wantWait {
+			t.Errorf("step %d: got %v %v, want %v %v", i, got, wait, s.want, s.wantWait)
+		}
+	}
+}
+
+func TestHandler_Limits(t *testing.T) {
+	hasDeadline := mock.MatchedBy(func(ctx context.Context) bool {
+		_, ok := ctx.Deadline()
+		return ok
+	})
+	tests := []struct {
+		name        string
+		limits      config.Limits
+		auth        config.Auth
+		header      http.Header
+		spoof       bool // X-Forwarded-For is different in every request
+		method      string
+		path        string
+		body        string
+		requests    int
+		serviceMock serviceMock
+		wantCode    int
+	}{
+		{
+			name:     "OK#1",
+			limits:   config.Limits{RatePerSecond: 1, Burst: 2},
+			method:   http.MethodGet,
+			path:     "/api/v1/jobs",
+			requests: 2,
+			serviceMock: func(c *mocks.Service) {
+				c.Mock.On("GetJobs", mock.Anything, mock.Anything).Return(shema.Page[shema.Job]{}, nil).Times(2)
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "RATE#1",
+			limits:   config.Limits{RatePerSecond: 1, Burst: 2},
+			method:   http.MethodGet,
+			path:     "/api/v1/jobs",
+			requests: 3,
+			serviceMock: func(c *mocks.Service) {
+				c.Mock.On("GetJobs", mock.Anything, mock.Anything).Return(shema.Page[shema.Job]{}, nil).Times(2)
+			},
+			wantCode: http.StatusTooManyRequests,
+		},
+		{
+			name:     "RATE#2",
+			limits:   config.Limits{RatePerSecond: 1, Burst: 1},
+			spoof:    true,
+			method:   http.MethodGet,
+			path:     "/api/v1/jobs",
+			requests: 5,
+			serviceMock: func(c *mocks.Service) {
+				c.Mock.On("GetJobs", mock.Anything, mock.Anything).Return(shema.Page[shema.Job]{}, nil).Times(1)
+			},
+			wantCode: http.StatusTooManyRequests,
+		},
+		{
+			name:        "RATE#3",
+			limits:      config.Limits{RatePerSecond: 1, Burst: 2},
+			auth:        config.Auth{APIKeys: []config.APIKey{{Name: "a", Key: "secret", Role: RoleRead}}},
+			header:      http.Header{apiKeyHeader: {"guess"}},
+			method:      http.MethodGet,
+			path:        "/api/v1/jobs",
+			requests:    3,
+			serviceMock: func(c *mocks.Service) {},
+			wantCode:    http.StatusTooManyRequests,
+		},
+		{
+			name:        "BODY#1",
+			limits:      config.Limits{MaxBodyBytes: 16},
+			method:      http.MethodPost,
+			path:        "/api/all",
+			body:        `{"unit_guid": "01749246-9617-585e-9e19-157ccad61ee2"}`,
+			requests:    1,
+			serviceMock: func(c *mocks.Service) {},
+			wantCode:    http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:        "BODY#2",
+			limits:      config.Limits{MaxBodyBytes: 1 << 20, MaxUploadBytes: 2},
+			method:      http.MethodPost,
+			path:        "/api/v1/files?name=a.tsv",
+			body:        "row",
+			requests:    1,
+			serviceMock: func(c *mocks.Service) {},
+			wantCode:    http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:     "TIMEOUT#1",
+			limits:   config.Limits{RequestTimeout: 30},
+			method:   http.MethodGet,
+			path:     "/api/v1/jobs",
+			requests: 1,
+			serviceMock: func(c *mocks.Service) {
+				c.Mock.On("GetJobs", hasDeadline, mock.Anything).Return(shema.Page[shema.Job]{}, context.DeadlineExceeded).Times(1)
+			},
+			wantCode: http.StatusGatewayTimeout,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := mocks.NewService(t)
+			h := NewHandler(service, config.Config{Limits: tt.limits, Auth: tt.auth}, zap.NewNop())
+			tt.serviceMock(service)
+
+			var w *httptest.ResponseRecorder
+			for i := 0; i < tt.requests; i++ {
+				w = httptest.NewRecorder()
+				req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+				for k, v := range tt.header {
+					req.Header[k] = v
+				}
+				if tt.spoof {
+					req.Header.Set("X-Forwarded-For", fmt.Sprintf("10.0.0.%d", i))
+				}
+				h.engine.ServeHTTP(w, req)
+			}
+
+			if w.Code != tt.wantCode {
+				t.Errorf("got %d, want %d: %s", w.Code, tt.wantCode, w.Body.String())
+			}
+			if tt.wantCode == http.StatusTooManyRequests && w.Header().Get("Retry-After") != "1" {
+				t.Errorf("got Retry-After %q, want 1", w.Header().Get("Retry-After"))
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"goTSVParser/internal/logging"
+	"io"
+	"net/http"
+	"time"
+)
+
+const (
+	requestIDHeader = "X-Request-ID"
+	maxRequestID    = 128
+)
+
+// requestLog take request id from header or generate it, put logger with the id into request context
+// and log request when it is done
+func (s *Handler) requestLog(c *gin.Context) {
+	start := time.Now()
+	id := c.GetHeader(requestIDHeader)
+	if !validRequestID(id) {
+		id = newRequestID()
+	}
+	c.Header(requestIDHeader, id)
+	log := s.logger.With(logging.RequestID(id))
+	c.Request = c.Request.WithContext(logging.With(c.Request.Context(), log))
+
+	c.Next()
+
+	status := c.Writer.Status()
+	fields := []zap.Field{
+		zap.String("method", c.Request.Method),
+		zap.String("path", c.Request.URL.Path),
+		zap.String("route", c.FullPath()),
+		zap.Int("status", status),
+		zap.Int("size", c.Writer.Size()),
+		zap.String("client_ip", c.ClientIP()),
+		logging.Duration(time.Since(start)),
+	}
+	if principal := c.GetString("principal"); principal != "" {
+		fields = append(fields, zap.String("principal", principal))
+	}
+	switch {
+	case status >= http.StatusInternalServerError:
+		log.Error("request", fields...)
+	case status >= http.StatusBadRequest:
+		log.Warn("request", fields...)
+	default:
+		log.Info("request", fields...)
+	}
+}
+
+// recovery answer 500 on panic and log it with stack
+func (s *Handler) recovery() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, recovered interface{}) {
+		logging.From(c.Request.Context(), s.logger).Error("panic in handler", zap.Any("panic", recovered), zap.Stack("stack"))
+		abortErr(c, http.StatusInternalServerError, "internal", "internal server error", nil)
+	})
+}
+
+// validRequestID accept ids of callers which are safe to log and echo back
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestID {
+		return false
+	}
+	for _, r := range id {
+		if r < '!' || r > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"errors"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+	"goTSVParser/config"
+	"goTSVParser/internal/domains/mocks"
+	"goTSVParser/internal/shema"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequestLog(t *testing.T) {
+	tests := []struct {
+		name      string
+		requestID string
+		err       error
+		wantID    string
+		wantLogs  []string
+	}{
+		{
+			name:      "OK#1",
+			requestID: "req-1",
+			wantID:    "req-1",
+			wantLogs:  []string{"request"},
+		},
+		{
+			name:     "OK#2",
+			err:      errors.New("db is broken"),
+			wantLogs: []string{"internal error", "request"},
+		},
+		{
+			name:      "BAD#1",
+			requestID: "bad id\n",
+			wantLogs:  []string{"request"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, logs := observer.New(zap.InfoLevel)
+			service := mocks.NewService(t)
+			service.Mock.On("GetJob", mock.Anything, "abc").Return(shema.Job{ID: "abc"}, tt.err).Times(1)
+			h := NewHandler(service, config.Config{}, zap.New(core))
+
+			w := httptest.NewRecorder()
+			request := httptest.NewRequest(http.MethodGet, "/api/v1/jobs/abc", nil)
+			if tt.requestID != "" {
+				request.Header.Set(requestIDHeader, tt.requestID)
+			}
+			h.engine.ServeHTTP(w, request)
+
+			id := w.Header().Get(requestIDHeader)
+			if tt.wantID != "" && id != tt.wantID {
+				t.Errorf("got request id %q, want %q", id, tt.wantID)
+			}
+			if tt.wantID == "" && len(id) != 16 {
+				t.Errorf("got request id %q, want generated one", id)
+			}
+
+			// startup logs have no request id
+			entries := logs.FilterFieldKey("request_id").AllUntimed()
+			if len(entries) != len(tt.wantLogs) {
+				t.Fatalf("got %d logs, want %v", len(entries), tt.wantLogs)
+			}
+			for i, e := range entries {
+				if e.Message != tt.wantLogs[i] {
+					t.Errorf("got log %q, want %q", e.Message, tt.wantLogs[i])
+				}
+				if e.ContextMap()["request_id"] != id {
+					t.Errorf("log %q has request id %v, want %s", e.Message, e.ContextMap()["request_id"], id)
+				}
+			}
+			if route := entries[len(entries)-1].ContextMap()["route"]; route != "/api/v1/jobs/:id" {
+				t.Errorf("got route %v", route)
+			}
+		})
+	}
+}
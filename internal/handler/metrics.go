@@ -0,0 +1,29 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"goTSVParser/internal/metrics"
+	"strconv"
+	"time"
+)
+
+var metricsHandler = promhttp.HandlerFor(metrics.Registry, promhttp.HandlerOpts{})
+
+// Metrics serve prometheus metrics
+func (s *Handler) Metrics(c *gin.Context) {
+	metricsHandler.ServeHTTP(c.Writer, c.Request)
+}
+
+// httpMetrics count requests by route pattern, so path params don't make new series
+func httpMetrics(c *gin.Context) {
+	start := time.Now()
+	c.Next()
+
+	route := c.FullPath()
+	if route == "" {
+		route = "unmatched"
+	}
+	metrics.HTTPRequests.WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).Inc()
+	metrics.Since(metrics.HTTPDuration.WithLabelValues(c.Request.Method, route), start)
+}
@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+	"goTSVParser/config"
+	"goTSVParser/internal/domains/mocks"
+	"goTSVParser/internal/shema"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandler_Metrics(t *testing.T) {
+	service := mocks.NewService(t)
+	service.Mock.On("GetJob", mock.Anything, "abc").Return(shema.Job{ID: "abc"}, nil).Times(1)
+	h := NewHandler(service, config.Config{}, zap.NewNop())
+
+	for _, path := range []string{"/api/v1/jobs/abc", "/no/such/route"} {
+		h.engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	w := httptest.NewRecorder()
+	h.engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("got %d, want %d", w.Code, http.StatusOK)
+	}
+	for _, want := range []string{
+		`http_requests_total{code="200",method="GET",route="/api/v1/jobs/:id"}`,
+		`http_requests_total{code="404",method="GET",route="unmatched"}`,
+		`http_request_duration_seconds_bucket{method="GET",route="/api/v1/jobs/:id",le="0.005"}`,
+		`tsv_files_failed_total{stage="parse"} 0`,
+		`tsv_files_discovered_total 0`,
+	} {
+		if !strings.Contains(w.Body.String(), want) {
+			t.Errorf("metrics don't have %s", want)
+		}
+	}
+}
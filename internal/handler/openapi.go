@@ -0,0 +1,277 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	swaggerFiles "github.com/swaggo/files/v2"
+	"go.uber.org/zap"
+	"goTSVParser/internal/shema"
+	"goTSVParser/internal/workers"
+	"net/http"
+	"reflect"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	openAPIPath = "/api/openapi.json"
+	docsPath    = "/api/docs/"
+)
+
+var swaggerInitializer = []byte(`window.onload = function() {
+  window.ui = SwaggerUIBundle({
+    url: "` + openAPIPath + `",
+    dom_id: '#swagger-ui',
+    deepLinking: true,
+    presets: [SwaggerUIBundle.presets.apis, SwaggerUIStandalonePreset],
+    layout: "StandaloneLayout"
+  });
+};
+`)
+
+// openAPIHandler serve spec generated from routes
+func openAPIHandler(api []route, logger *zap.Logger) gin.HandlerFunc {
+	spec, err := json.Marshal(openAPI(api))
+	if err != nil {
+		logger.Fatal("failed to generate openapi spec", zap.Error(err))
+	}
+	return func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/json; charset=utf-8", spec)
+	}
+}
+
+// docsHandler serve embedded swagger ui pointed to generated spec
+func docsHandler(c *gin.Context) {
+	file := c.Param("file")
+	if file == "/swagger-initializer.js" {
+		c.Data(http.StatusOK, "application/javascript; charset=utf-8", swaggerInitializer)
+		return
+	}
+	c.FileFromFS(file, http.FS(swaggerFiles.FS))
+}
+
+type object = map[string]interface{}
+
+var pathParam = regexp.MustCompile(`:(\w+)`)
+
+// openAPIPathOf convert gin path /units/:guid to openapi path /units/{guid}
+func openAPIPathOf(path string) string {
+	return pathParam.ReplaceAllString(path, "{$1}")
+}
+
+func openAPI(api []route) object {
+	s := &schemas{components: object{}}
+	s.of(reflect.TypeOf(shema.Error{}))
+
+	paths := object{}
+	for _, r := range api {
+		path := openAPIPathOf(r.path)
+		if paths[path] == nil {
+			paths[path] = object{}
+		}
+		paths[path].(object)[strings.ToLower(r.method)] = s.operation(r)
+	}
+
+	return object{
+		"openapi": "3.0.3",
+		"info": object{
+			"title":   "goTSVParser API",
+			"version": "1.0.0",
+		},
+		"paths": paths,
+		"components": object{
+			"schemas": s.components,
+			"securitySchemes": object{
+				"apiKey": object{"type": "apiKey", "in": "header", "name": apiKeyHeader},
+				"bearer": object{"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+			},
+		},
+	}
+}
+
+func (s *schemas) operation(r route) object {
+	params := []object{}
+	for _, m := range pathParam.FindAllStringSubmatch(r.path, -1) {
+		params = append(params, object{"name": m[1], "in": "path", "required": true, "schema": object{"type": "string"}})
+	}
+	if r.query != nil {
+		t := reflect.TypeOf(r.query)
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			name := f.Tag.Get("form")
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			params = append(params, object{"name": name, "in": "query", "schema": s.of(ft)})
+		}
+	}
+
+	op := object{
+		"summary":     r.summary,
+		"operationId": operationID(r.handler),
+		"parameters":  params,
+		"security":    []object{},
+	}
+	if r.role != "" {
+		op["security"] = []object{{"apiKey": []string{}}, {"bearer": []string{}}}
+		op["x-role"] = r.role
+	}
+	if r.deprecated != "" {
+		op["deprecated"] = true
+		op["description"] = "Use " + r.deprecated
+	}
+	if r.legacy {
+		op["operationId"] = operationID(r.handler) + "Legacy"
+	}
+
+	if r.body != nil {
+		op["requestBody"] = object{
+			"required": true,
+			"content":  object{"application/json": object{"schema": s.of(reflect.TypeOf(r.body))}},
+		}
+	}
+	if r.upload {
+		binary := object{"type": "string", "format": "binary"}
+		op["parameters"] = append(params, object{"name": "name", "in": "query", "description": "file name of raw body",
+			"schema": object{"type": "string"}})
+		op["requestBody"] = object{
+			"required": true,
+			"content": object{
+				"multipart/form-data": object{"schema": object{
+					"type":       "object",
+					"properties": object{"file": binary},
+					"required":   []string{"file"},
+				}},
+				"text/tab-separated-values": object{"schema": binary},
+			},
+		}
+	}
+
+	content := object{}
+	if r.response != nil {
+		content["application/json"] = object{"schema": s.of(reflect.TypeOf(r.response))}
+	}
+	for _, contentType := range r.produces {
+		content[contentType] = object{"schema": object{"type": "string", "format": "binary"}}
+	}
+	responses := object{
+		strconv.Itoa(r.status): object{"description": http.StatusText(r.status), "content": content},
+		"default": object{
+			"description": "Error",
+			"content":     object{"application/json": object{"schema": s.of(reflect.TypeOf(shema.Error{}))}},
+		},
+	}
+	for status, description := range r.responses {
+		responses[strconv.Itoa(status)] = object{"description": description}
+	}
+	op["responses"] = responses
+	return op
+}
+
+// operationID is name of handler method, e.g. GetUnits
+func operationID(h gin.HandlerFunc) string {
+	name := runtime.FuncForPC(reflect.ValueOf(h).Pointer()).Name()
+	name = strings.TrimSuffix(name, "-fm")
+	return name[strings.LastIndex(name, ".")+1:]
+}
+
+func reportTypes() []string {
+	var result []string
+	for _, format := range workers.Formats {
+		contentType, _ := workers.ContentType(format)
+		result = append(result, contentType)
+	}
+	return result
+}
+
+// schemas collect json schemas of named structs into components
+type schemas struct {
+	components object
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func (s *schemas) of(t reflect.Type) object {
+	switch {
+	case t == timeType:
+		return object{"type": "string", "format": "date-time"}
+	case t.Kind() == reflect.Pointer:
+		schema := object{"nullable": true}
+		for k, v := range s.of(t.Elem()) {
+			schema[k] = v
+		}
+		if ref, ok := schema["$ref"]; ok {
+			return object{"allOf": []object{{"$ref": ref}}, "nullable": true}
+		}
+		return schema
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return object{"type": "string"}
+	case reflect.Bool:
+		return object{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return object{"type": "integer"}
+	case reflect.Int64, reflect.Uint64:
+		return object{"type": "integer", "format": "int64"}
+	case reflect.Float32, reflect.Float64:
+		return object{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return object{"type": "string", "format": "byte"}
+		}
+		return object{"type": "array", "items": s.of(t.Elem())}
+	case reflect.Map:
+		return object{"type": "object", "additionalProperties": s.of(t.Elem())}
+	case reflect.Struct:
+		// generic types like Page[Tsv] are inlined
+		if t.Name() == "" || strings.Contains(t.Name(), "[") {
+			return s.object(t)
+		}
+		if _, ok := s.components[t.Name()]; !ok {
+			s.components[t.Name()] = object{}
+			s.components[t.Name()] = s.object(t)
+		}
+		return object{"$ref": "#/components/schemas/" + t.Name()}
+	default:
+		return object{}
+	}
+}
+
+func (s *schemas) object(t reflect.Type) object {
+	properties := object{}
+	var required []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		properties[name] = s.of(f.Type)
+		if opts != "omitempty" && f.Type.Kind() != reflect.Pointer {
+			required = append(required, name)
+		}
+	}
+	schema := object{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
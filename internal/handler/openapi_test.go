@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"encoding/json"
+	"go.uber.org/zap"
+	"goTSVParser/config"
+	"goTSVParser/internal/domains/mocks"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+)
+
+type spec struct {
+	Paths map[string]map[string]struct {
+		OperationID string                      `json:"operationId"`
+		Parameters  []struct{ Name, In string } `json:"parameters"`
+		Responses   map[string]interface{}      `json:"responses"`
+	} `json:"paths"`
+	Components struct {
+		Schemas map[string]interface{} `json:"schemas"`
+	} `json:"components"`
+}
+
+func TestOpenAPI_Routes(t *testing.T) {
+	h := NewHandler(mocks.NewService(t), config.Config{}, zap.NewNop())
+
+	w := httptest.NewRecorder()
+	h.engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, openAPIPath, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("got %d, want %d", w.Code, http.StatusOK)
+	}
+	var got spec
+	err := json.Unmarshal(w.Body.Bytes(), &got)
+	if err != nil {
+		t.Fatalf("failed json: %v", err)
+	}
+
+	var inSpec []string
+	for path, ops := range got.Paths {
+		for method, op := range ops {
+			inSpec = append(inSpec, strings.ToUpper(method)+" "+path)
+			if len(op.Responses) < 2 {
+				t.Errorf("%s %s: got responses %v, want success and default", method, path, op.Responses)
+			}
+			for _, p := range op.Parameters {
+				if p.In == "path" && !strings.Contains(path, "{"+p.Name+"}") {
+					t.Errorf("%s %s: path param %s is not in path", method, path, p.Name)
+				}
+			}
+		}
+	}
+
+	var inRouter []string
+	for _, r := range h.engine.Routes() {
+		if r.Path == openAPIPath || strings.HasPrefix(r.Path, docsPath) {
+			continue
+		}
+		inRouter = append(inRouter, r.Method+" "+openAPIPathOf(r.Path))
+	}
+
+	sort.Strings(inSpec)
+	sort.Strings(inRouter)
+	if strings.Join(inSpec, "\n") != strings.Join(inRouter, "\n") {
+		t.Errorf("spec and routes diverge\nspec:\n%s\nroutes:\n%s", strings.Join(inSpec, "\n"), strings.Join(inRouter, "\n"))
+	}
+
+	for _, name := range []string{"Error", "Tsv", "Job", "CatalogItem", "Stats"} {
+		if _, ok := got.Components.Schemas[name]; !ok {
+			t.Errorf("schema %s is missing", name)
+		}
+	}
+}
+
+func TestOpenAPI_Docs(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		wantCode int
+		wantBody string
+	}{
+		{name: "OK#1", path: docsPath, wantCode: http.StatusOK, wantBody: "swagger-ui"},
+		{name: "OK#2", path: docsPath + "swagger-initializer.js", wantCode: http.StatusOK, wantBody: openAPIPath},
+		{name: "OK#3", path: docsPath + "swagger-ui-bundle.js", wantCode: http.StatusOK},
+		{name: "BAD#1", path: docsPath + "missing.js", wantCode: http.StatusNotFound},
+	}
+
+	h := NewHandler(mocks.NewService(t), config.Config{}, zap.NewNop())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			h.engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if w.Code != tt.wantCode {
+				t.Errorf("got %d, want %d", w.Code, tt.wantCode)
+			}
+			if !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("body doesn't contain %s", tt.wantBody)
+			}
+		})
+	}
+}
@@ -1,7 +1,118 @@
 package handler
 
-import "github.com/gin-gonic/gin"
+import (
+	"github.com/gin-gonic/gin"
+	"goTSVParser/internal/shema"
+	"net/http"
+)
+
+// route is api endpoint with description used to generate openapi spec
+type route struct {
+	method     string
+	path       string
+	handler    gin.HandlerFunc
+	role       string // empty for open routes, they are not rate limited
+	summary    string
+	query      interface{} // struct bound from query, its form tags become params
+	body       interface{} // json request body
+	upload     bool        // file in multipart field "file" or raw body
+	status     int
+	response   interface{} // json response body
+	produces   []string    // content types of not json response
+	responses  map[int]string
+	deprecated string // path of successor
+	legacy     bool   // alias of route from before /api/v1
+}
+
+// legacyPaths of routes from before /api/v1 by their successors, they are kept for old clients
+var legacyPaths = map[string]string{
+	"/api/v1/units":               "/api/units",
+	"/api/v1/units/:guid/:report": "/api/units/:guid/:report",
+	"/api/v1/inventory":           "/api/inventory",
+	"/api/v1/search":              "/api/search",
+	"/api/v1/stats":               "/api/stats",
+	"/api/v1/files":               "/api/files",
+	"/api/v1/jobs":                "/api/jobs",
+	"/api/v1/jobs/:id":            "/api/jobs/:id",
+}
+
+func routes(h *Handler) []route {
+	api := []route{
+		{method: http.MethodGet, path: "/api/v1/units", handler: h.GetUnits, role: RoleRead, summary: "Known unit guids",
+			query: shema.CatalogFilter{}, status: http.StatusOK, response: shema.Page[shema.CatalogItem]{}},
+		{method: http.MethodGet, path: "/api/v1/units/:guid/occurrences", handler: h.GetOccurrences, role: RoleRead, summary: "Messages of unit",
+			query: shema.Request{}, status: http.StatusOK, response: shema.Page[shema.Tsv]{}},
+		{method: http.MethodGet, path: "/api/v1/units/:guid/:report", handler: h.GetReport, role: RoleRead, summary: "Report of unit, report is report.pdf, report.svg, report.xlsx or report.html",
+			status: http.StatusOK, produces: reportTypes(), responses: map[int]string{http.StatusNotModified: "Report is not changed since If-None-Match etag"}},
+		{method: http.MethodGet, path: "/api/v1/schemas", handler: h.GetSchemas, role: RoleRead, summary: "Schemas of files with key and columns, occurrence is the last one",
+			status: http.StatusOK, response: []shema.SchemaInfo{}},
+		{method: http.MethodGet, path: "/api/v1/schemas/:schema/keys/:key/rows", handler: h.GetRecords, role: RoleRead, summary: "Rows of key of schema",
+			query: shema.RecordRequest{}, status: http.StatusOK, response: shema.Page[map[string]interface{}]{}},
+		{method: http.MethodGet, path: "/api/v1/schemas/:schema/keys/:key/:report", handler: h.GetRecordsReport, role: RoleRead, summary: "Report of key of schema, report is report.pdf, report.svg, report.xlsx or report.html",
+			status: http.StatusOK, produces: reportTypes(), responses: map[int]string{http.StatusNotModified: "Report is not changed since If-None-Match etag"}},
+		{method: http.MethodGet, path: "/api/v1/inventory", handler: h.GetInventory, role: RoleRead, summary: "Known inventory ids",
+			query: shema.CatalogFilter{}, status: http.StatusOK, response: shema.Page[shema.CatalogItem]{}},
+		{method: http.MethodGet, path: "/api/v1/search", handler: h.Search, role: RoleRead, summary: "Search messages, any other query param filters by field with the same name",
+			query: shema.SearchRequest{}, status: http.StatusOK, response: shema.Page[map[string]interface{}]{}},
+		{method: http.MethodGet, path: "/api/v1/stats", handler: h.GetStats, role: RoleRead, summary: "Count messages by class, level, area, file and unit",
+			query: shema.StatsFilter{}, status: http.StatusOK, response: shema.Stats{}},
+		{method: http.MethodPost, path: "/api/v1/files", handler: h.Upload, role: RoleAdmin, summary: "Upload input file",
+			upload: true, status: http.StatusAccepted, response: shema.Job{}},
+		{method: http.MethodGet, path: "/api/v1/jobs", handler: h.GetJobs, role: RoleRead, summary: "Processed files",
+			query: shema.JobFilter{}, status: http.StatusOK, response: shema.Page[shema.Job]{}},
+		{method: http.MethodGet, path: "/api/v1/jobs/:id", handler: h.GetJob, role: RoleRead, summary: "Status of processed file",
+			status: http.StatusOK, response: shema.Job{}},
+		{method: http.MethodGet, path: "/healthz", handler: h.Healthz, summary: "Liveness probe, checks that watcher scans directory",
+			status: http.StatusOK, response: shema.Health{}, responses: map[int]string{http.StatusServiceUnavailable: "Check failed, body has details"}},
+		{method: http.MethodGet, path: "/readyz", handler: h.Readyz, summary: "Readiness probe, checks watcher and db connection",
+			status: http.StatusOK, response: shema.Health{}, responses: map[int]string{http.StatusServiceUnavailable: "Check failed, body has details"}},
+		{method: http.MethodGet, path: "/metrics", handler: h.Metrics, summary: "Prometheus metrics of pipeline and api",
+			status: http.StatusOK, produces: []string{"text/plain"}},
+		{method: http.MethodPost, path: "/api/all", handler: h.GetAll, role: RoleRead, summary: "Messages of unit",
+			body: shema.Request{}, status: http.StatusOK, response: shema.Page[shema.Tsv]{},
+			deprecated: "/api/v1/units/{guid}/occurrences"},
+	}
+	for _, r := range api {
+		if path, ok := legacyPaths[r.path]; ok {
+			r.deprecated, r.path, r.legacy = openAPIPathOf(r.path), path, true
+			api = append(api, r)
+		}
+	}
+	return api
+}
 
 func Route(c *gin.Engine, h *Handler) {
-	c.POST("/api/all", h.GetAll)
+	c.Use(h.requestLog, h.recovery(), httpMetrics, tracing)
+	c.HandleMethodNotAllowed = true
+	c.NoRoute(func(c *gin.Context) {
+		abortErr(c, http.StatusNotFound, "not_found", "route not found", nil)
+	})
+	c.NoMethod(func(c *gin.Context) {
+		abortErr(c, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed", nil)
+	})
+
+	api := routes(h)
+	rateLimit, authFailures := h.rateLimiters()
+	for _, r := range api {
+		handlers := h.limits(r)
+		if r.role != "" {
+			handlers = append(handlers, authFailures, h.auth.Require(r.role), rateLimit)
+		}
+		if r.deprecated != "" {
+			handlers = append(handlers, deprecated(r.deprecated))
+		}
+		c.Handle(r.method, r.path, append(handlers, r.handler)...)
+	}
+
+	c.GET(openAPIPath, openAPIHandler(api, h.logger))
+	c.GET(docsPath+"*file", docsHandler)
+}
+
+// deprecated mark response of old endpoint with link to its successor
+func deprecated(successor string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Deprecation", "true")
+		c.Header("Link", "<"+successor+`>; rel="successor-version"`)
+		c.Next()
+	}
 }
@@ -0,0 +1,240 @@
+package handler
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"go.uber.org/zap"
+	"goTSVParser/config"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	defaultTLSDir = "tls"
+	// self-signed certificate is regenerated when it expires sooner than this
+	renewBefore = 30 * 24 * time.Hour
+	// cert files are checked for change not more often than this
+	reloadInterval = time.Second
+)
+
+var tlsVersions = map[string]uint16{
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// certManager serve certificate from files and reload it when files change
+type certManager struct {
+	certFile string
+	keyFile  string
+
+	mu        sync.Mutex
+	cert      *tls.Certificate
+	modTime   time.Time
+	checkedAt time.Time
+	now       func() time.Time
+	logger    *zap.Logger
+}
+
+func newCertManager(certFile, keyFile string, logger *zap.Logger) (*certManager, error) {
+	m := &certManager{certFile: certFile, keyFile: keyFile, now: time.Now, logger: logger}
+	if err := m.load(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (m *certManager) load() error {
+	cert, err := tls.LoadX509KeyPair(m.certFile, m.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load certificate: %w", err)
+	}
+	m.cert = &cert
+	m.modTime = m.lastModified()
+	return nil
+}
+
+func (m *certManager) lastModified() time.Time {
+	var last time.Time
+	for _, f := range []string{m.certFile, m.keyFile} {
+		if info, err := os.Stat(f); err == nil && info.ModTime().After(last) {
+			last = info.ModTime()
+		}
+	}
+	return last
+}
+
+// GetCertificate return current certificate, old one is kept if changed files can't be loaded
+func (m *certManager) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if now := m.now(); now.Sub(m.checkedAt) >= reloadInterval {
+		m.checkedAt = now
+		if !m.lastModified().Equal(m.modTime) {
+			old := m.cert
+			if err := m.load(); err != nil {
+				m.logger.Error("failed to reload certificate, keeping old one", zap.String("cert", m.certFile), zap.Error(err))
+				m.cert = old
+			} else {
+				m.logger.Info("certificate reloaded", zap.String("cert", m.certFile))
+			}
+		}
+	}
+	return m.cert, nil
+}
+
+// tlsConfig build server tls config from config, with self-signed certificate when cert files are not set
+func tlsConfig(cfg config.Config, auth *Auth, logger *zap.Logger) (*tls.Config, error) {
+	c := &tls.Config{MinVersion: tls.VersionTLS12}
+	if cfg.TLSMinVersion != "" {
+		v, ok := tlsVersions[cfg.TLSMinVersion]
+		if !ok {
+			return nil, fmt.Errorf("unsupported tls version %s", cfg.TLSMinVersion)
+		}
+		c.MinVersion = v
+	}
+	for _, name := range cfg.TLSCiphers {
+		id, ok := cipherSuite(name)
+		if !ok {
+			return nil, fmt.Errorf("unsupported cipher suite %s", name)
+		}
+		c.CipherSuites = append(c.CipherSuites, id)
+	}
+
+	certFile, keyFile := cfg.Certificate, cfg.PrivateKey
+	if certFile == "" || keyFile == "" {
+		dir := cfg.TLSDir
+		if dir == "" {
+			dir = defaultTLSDir
+		}
+		var err error
+		certFile, keyFile, err = selfSignedCert(dir, certHosts(cfg), logger)
+		if err != nil {
+			return nil, err
+		}
+	}
+	certs, err := newCertManager(certFile, keyFile, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	c.GetCertificate = certs.GetCertificate
+	auth.applyTLS(c)
+	return c, nil
+}
+
+// cipherSuite find secure cipher suite by name, e.g. TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256
+func cipherSuite(name string) (uint16, bool) {
+	for _, s := range tls.CipherSuites() {
+		if s.Name == name {
+			return s.ID, true
+		}
+	}
+	return 0, false
+}
+
+// certHosts is host of listen address and configured names, or local names when listening on all interfaces
+func certHosts(cfg config.Config) []string {
+	host, _, err := net.SplitHostPort(cfg.Host)
+	if err != nil {
+		host = cfg.Host
+	}
+	var hosts []string
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		hosts = append(hosts, "localhost", "127.0.0.1", "::1")
+		if name, err := os.Hostname(); err == nil {
+			hosts = append(hosts, name)
+		}
+	} else {
+		hosts = append(hosts, host)
+	}
+	return append(hosts, cfg.TLSHosts...)
+}
+
+// selfSignedCert reuse certificate in dir if it covers hosts and is not expiring, otherwise generate new one
+func selfSignedCert(dir string, hosts []string, logger *zap.Logger) (string, string, error) {
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	if certCovers(certFile, keyFile, hosts) {
+		return certFile, keyFile, nil
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return "", "", err
+	}
+	cert := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: hosts[0]},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().AddDate(1, 0, 0),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+	}
+	for _, h := range hosts {
+		if ip := net.ParseIP(h); ip != nil {
+			cert.IPAddresses = append(cert.IPAddresses, ip)
+		} else {
+			cert.DNSNames = append(cert.DNSNames, h)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, cert, cert, &key.PublicKey, key)
+	if err != nil {
+		return "", "", err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return "", "", err
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", "", fmt.Errorf("failed to create tls dir: %w", err)
+	}
+	err = os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to write key: %w", err)
+	}
+	err = os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to write certificate: %w", err)
+	}
+	logger.Info("generated self-signed certificate", zap.String("cert", certFile), zap.Strings("hosts", hosts))
+	return certFile, keyFile, nil
+}
+
+func certCovers(certFile, keyFile string, hosts []string) bool {
+	pair, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return false
+	}
+	cert, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil || time.Until(cert.NotAfter) < renewBefore {
+		return false
+	}
+	for _, h := range hosts {
+		if ip := net.ParseIP(h); ip != nil {
+			if !slices.ContainsFunc(cert.IPAddresses, ip.Equal) {
+				return false
+			}
+		} else if !slices.Contains(cert.DNSNames, h) {
+			return false
+		}
+	}
+	return true
+}
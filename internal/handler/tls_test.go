@@ -0,0 +1,204 @@
+package handler
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"go.uber.org/zap"
+	"goTSVParser/config"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSelfSignedCert(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "tls")
+	hosts := []string{"localhost", "127.0.0.1", "tsv.example.com"}
+
+	certFile, keyFile, err := selfSignedCert(dir, hosts, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert := leaf(t, certFile, keyFile)
+	if cert.PublicKeyAlgorithm != x509.ECDSA {
+		t.Errorf("got key %v, want ECDSA", cert.PublicKeyAlgorithm)
+	}
+	if !reflect.DeepEqual(cert.DNSNames, []string{"localhost", "tsv.example.com"}) {
+		t.Errorf("got dns names %v", cert.DNSNames)
+	}
+	if len(cert.IPAddresses) != 1 || !cert.IPAddresses[0].Equal(net.ParseIP("127.0.0.1")) {
+		t.Errorf("got ips %v", cert.IPAddresses)
+	}
+	if info, _ := os.Stat(keyFile); info.Mode().Perm() != 0600 {
+		t.Errorf("got key mode %v, want 0600", info.Mode().Perm())
+	}
+
+	// same hosts reuse certificate
+	if _, _, err = selfSignedCert(dir, hosts[:2], zap.NewNop()); err != nil {
+		t.Fatal(err)
+	}
+	if again := leaf(t, certFile, keyFile); again.SerialNumber.Cmp(cert.SerialNumber) != 0 {
+		t.Errorf("certificate is regenerated for the same hosts")
+	}
+
+	// new host needs new certificate
+	if _, _, err = selfSignedCert(dir, append(hosts, "other.example.com"), zap.NewNop()); err != nil {
+		t.Fatal(err)
+	}
+	if again := leaf(t, certFile, keyFile); again.SerialNumber.Cmp(cert.SerialNumber) == 0 {
+		t.Errorf("certificate is not regenerated for new host")
+	}
+}
+
+func TestCertManager_Reload(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile, err := selfSignedCert(filepath.Join(dir, "a"), []string{"a.example.com"}, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	m, err := newCertManager(certFile, keyFile, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.now = func() time.Time { return now }
+
+	newCert, newKey, err := selfSignedCert(filepath.Join(dir, "b"), []string{"b.example.com"}, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	copyFile(t, newCert, certFile)
+	copyFile(t, newKey, keyFile)
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(certFile, later, later)
+	os.Chtimes(keyFile, later, later)
+
+	now = now.Add(2 * reloadInterval)
+	got, _ := m.GetCertificate(nil)
+	cert, _ := x509.ParseCertificate(got.Certificate[0])
+	if cert.Subject.CommonName != "b.example.com" {
+		t.Errorf("got %s, want reloaded b.example.com", cert.Subject.CommonName)
+	}
+
+	// broken files keep old certificate
+	os.WriteFile(certFile, []byte("broken"), 0644)
+	os.Chtimes(certFile, later.Add(time.Minute), later.Add(time.Minute))
+	now = now.Add(2 * reloadInterval)
+	got, _ = m.GetCertificate(nil)
+	cert, _ = x509.ParseCertificate(got.Certificate[0])
+	if cert.Subject.CommonName != "b.example.com" {
+		t.Errorf("got %s, want kept b.example.com", cert.Subject.CommonName)
+	}
+}
+
+func TestTLSConfig(t *testing.T) {
+	tests := []struct {
+		name           string
+		cfg            config.Config
+		clientCA       bool
+		wantMinVersion uint16
+		wantCiphers    []uint16
+		wantClientAuth tls.ClientAuthType
+		wantErr        bool
+	}{
+		{
+			name:           "OK#1",
+			cfg:            config.Config{Host: "0.0.0.0:8081"},
+			wantMinVersion: tls.VersionTLS12,
+		},
+		{
+			name: "OK#2",
+			cfg: config.Config{Host: "tsv.example.com:8081", TLSMinVersion: "1.2",
+				TLSCiphers: []string{"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256"}},
+			wantMinVersion: tls.VersionTLS12,
+			wantCiphers:    []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256},
+		},
+		{
+			name:           "OK#3",
+			cfg:            config.Config{Host: "localhost:8081", TLSMinVersion: "1.3"},
+			wantMinVersion: tls.VersionTLS13,
+		},
+		{
+			name:           "OK#4",
+			cfg:            config.Config{Host: "localhost:8081", Auth: config.Auth{RequireCert: true}},
+			clientCA:       true,
+			wantMinVersion: tls.VersionTLS12,
+			wantClientAuth: tls.RequireAndVerifyClientCert,
+		},
+		{
+			name:           "OK#5",
+			cfg:            config.Config{Host: "localhost:8081"},
+			clientCA:       true,
+			wantMinVersion: tls.VersionTLS12,
+			wantClientAuth: tls.VerifyClientCertIfGiven,
+		},
+		{
+			name:    "BAD#1",
+			cfg:     config.Config{Host: "localhost:8081", TLSMinVersion: "1.0"},
+			wantErr: true,
+		},
+		{
+			name:    "BAD#2",
+			cfg:     config.Config{Host: "localhost:8081", TLSCiphers: []string{"TLS_RSA_WITH_RC4_128_SHA"}},
+			wantErr: true,
+		},
+		{
+			name:    "BAD#3",
+			cfg:     config.Config{Host: "localhost:8081", Certificate: "missing.pem", PrivateKey: "missing.key"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cfg.TLSDir = t.TempDir()
+			if tt.clientCA {
+				tt.cfg.Auth.ClientCA, _, _ = selfSignedCert(t.TempDir(), []string{"clients"}, zap.NewNop())
+			}
+			auth, err := NewAuth(tt.cfg.Auth)
+			if err != nil {
+				t.Fatal(err)
+			}
+			c, err := tlsConfig(tt.cfg, auth, zap.NewNop())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got err %v, want err %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if c.MinVersion != tt.wantMinVersion {
+				t.Errorf("got min version %x, want %x", c.MinVersion, tt.wantMinVersion)
+			}
+			if !reflect.DeepEqual(c.CipherSuites, tt.wantCiphers) {
+				t.Errorf("got ciphers %v, want %v", c.CipherSuites, tt.wantCiphers)
+			}
+			if c.ClientAuth != tt.wantClientAuth {
+				t.Errorf("got client auth %v, want %v", c.ClientAuth, tt.wantClientAuth)
+			}
+		})
+	}
+}
+
+func leaf(t *testing.T, certFile, keyFile string) *x509.Certificate {
+	t.Helper()
+	pair, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func copyFile(t *testing.T, from, to string) {
+	t.Helper()
+	data, err := os.ReadFile(from)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, to, data)
+}
@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("goTSVParser/internal/handler")
+
+// tracing start server span of request, continuing trace of caller from traceparent header,
+// request context carries the span into service and db queries
+func tracing(c *gin.Context) {
+	ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+	route := c.FullPath()
+	name := c.Request.Method + " " + route
+	if route == "" {
+		name = c.Request.Method
+	}
+	ctx, span := tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(
+		semconv.HTTPRequestMethodKey.String(c.Request.Method),
+		semconv.HTTPRoute(route),
+		semconv.URLPath(c.Request.URL.Path),
+	))
+	defer span.End()
+
+	c.Request = c.Request.WithContext(ctx)
+	c.Next()
+
+	status := c.Writer.Status()
+	span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+	if status >= 500 {
+		span.SetStatus(codes.Error, fmt.Sprintf("status %d", status))
+	}
+	if principal := c.GetString("principal"); principal != "" {
+		span.SetAttributes(attribute.String("principal", principal))
+	}
+}
@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"context"
+	"github.com/stretchr/testify/mock"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+	"go.uber.org/zap"
+	"goTSVParser/config"
+	"goTSVParser/internal/domains/mocks"
+	"goTSVParser/internal/shema"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer otel.SetTracerProvider(noop.NewTracerProvider())
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0736aa"
+	var inService trace.SpanContext
+	service := mocks.NewService(t)
+	service.Mock.On("GetJob", mock.MatchedBy(func(ctx context.Context) bool {
+		inService = trace.SpanContextFromContext(ctx)
+		return true
+	}), "abc").Return(shema.Job{ID: "abc"}, nil).Times(1)
+	h := NewHandler(service, config.Config{}, zap.NewNop())
+
+	request := httptest.NewRequest(http.MethodGet, "/api/v1/jobs/abc", nil)
+	request.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	h.engine.ServeHTTP(httptest.NewRecorder(), request)
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans, want 1", len(spans))
+	}
+	span := spans[0]
+	if span.Name() != "GET /api/v1/jobs/:id" {
+		t.Errorf("got name %s", span.Name())
+	}
+	if span.SpanContext().TraceID().String() != traceID || span.Parent().SpanID().String() != "00f067aa0ba902b7" {
+		t.Errorf("got trace %s parent %s, want trace of caller", span.SpanContext().TraceID(), span.Parent().SpanID())
+	}
+	if inService.SpanID() != span.SpanContext().SpanID() {
+		t.Errorf("service got span %s, want %s", inService.SpanID(), span.SpanContext().SpanID())
+	}
+}
@@ -0,0 +1,123 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"slices"
+	"sync"
+	"time"
+)
+
+type component struct {
+	name   string
+	run    func(ctx context.Context) error
+	cancel context.CancelFunc
+	done   chan error
+}
+
+type hook struct {
+	name  string
+	stop  func(ctx context.Context) error
+	after []string // components which must be stopped before hook
+}
+
+// Lifecycle run components until first failure or until ctx is done,
+// then stop components in reverse order of start and run stop hooks in order
+type Lifecycle struct {
+	timeout    time.Duration
+	logger     *zap.Logger
+	components []*component
+	hooks      []hook
+	mu         sync.Mutex
+	deadline   time.Time
+}
+
+type lifecycleKey struct{}
+
+func New(timeout time.Duration, logger *zap.Logger) *Lifecycle {
+	return &Lifecycle{timeout: timeout, logger: logger}
+}
+
+// Go add component, run must return when its ctx is done
+func (l *Lifecycle) Go(name string, run func(ctx context.Context) error) {
+	l.components = append(l.components, &component{name: name, run: run})
+}
+
+// OnStop add hook which runs after all components stopped, e.g. closing db.
+// Hook is skipped when any of after components is not stopped in time
+func (l *Lifecycle) OnStop(name string, stop func(ctx context.Context) error, after ...string) {
+	l.hooks = append(l.hooks, hook{name: name, stop: stop, after: after})
+}
+
+// StopContext is done at shutdown deadline of application, component drains with it after its ctx is done.
+// Outside of lifecycle or before stop it is done after timeout
+func StopContext(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if l, ok := ctx.Value(lifecycleKey{}).(*Lifecycle); ok {
+		l.mu.Lock()
+		deadline := l.deadline
+		l.mu.Unlock()
+		if !deadline.IsZero() {
+			return context.WithDeadline(context.Background(), deadline)
+		}
+	}
+	return context.WithTimeout(context.Background(), timeout)
+}
+
+// Run return nil on clean shutdown and error of failed or not stopped components and hooks
+func (l *Lifecycle) Run(ctx context.Context) error {
+	failed := make(chan string, len(l.components))
+	for _, c := range l.components {
+		var runCtx context.Context
+		runCtx, c.cancel = context.WithCancel(context.WithValue(context.Background(), lifecycleKey{}, l))
+		c.done = make(chan error, 1)
+		go func(c *component) {
+			err := c.run(runCtx)
+			c.done <- err
+			failed <- c.name
+		}(c)
+	}
+
+	select {
+	case <-ctx.Done():
+		l.logger.Info("stopping application")
+	case name := <-failed:
+		l.logger.Warn("component stopped, stopping application", zap.String("component", name))
+	}
+
+	// every stage gets what is left of one deadline
+	l.mu.Lock()
+	l.deadline = time.Now().Add(l.timeout)
+	l.mu.Unlock()
+	stopCtx, cancel := context.WithDeadline(context.Background(), l.deadline)
+	defer cancel()
+
+	var errs []error
+	running := make(map[string]bool)
+	for i := len(l.components) - 1; i >= 0; i-- {
+		c := l.components[i]
+		c.cancel()
+		select {
+		case err := <-c.done:
+			if err != nil && !errors.Is(err, context.Canceled) {
+				errs = append(errs, fmt.Errorf("%s: %w", c.name, err))
+			}
+		case <-stopCtx.Done():
+			running[c.name] = true
+			errs = append(errs, fmt.Errorf("%s: not stopped in %s", c.name, l.timeout))
+		}
+	}
+
+	for _, h := range l.hooks {
+		if i := slices.IndexFunc(h.after, func(name string) bool { return running[name] }); i >= 0 {
+			l.logger.Warn("stop hook is skipped, component is still running", zap.String("hook", h.name),
+				zap.String("component", h.after[i]))
+			continue
+		}
+		if err := h.stop(stopCtx); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", h.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,174 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"go.uber.org/zap"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLifecycle_Run(t *testing.T) {
+	errBoom := errors.New("boom")
+	tests := []struct {
+		name      string
+		failing   bool
+		stuck     bool
+		hookErr   error
+		wantOrder []string
+		wantErr   string
+	}{
+		{
+			name:      "OK#1",
+			wantOrder: []string{"stop http", "stop worker", "hook storage", "hook logger"},
+		},
+		{
+			name:      "FAIL#1",
+			failing:   true,
+			wantOrder: []string{"stop http", "hook storage", "hook logger"},
+			wantErr:   "worker: boom",
+		},
+		{
+			name:      "FAIL#2",
+			stuck:     true,
+			wantOrder: []string{"stop http", "hook storage", "hook logger"},
+			wantErr:   "worker: not stopped in",
+		},
+		{
+			name:      "FAIL#3",
+			hookErr:   errBoom,
+			wantOrder: []string{"stop http", "stop worker", "hook storage", "hook logger"},
+			wantErr:   "storage: boom",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var order []string
+			record := func(s string) {
+				mu.Lock()
+				order = append(order, s)
+				mu.Unlock()
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			app := New(100*time.Millisecond, zap.NewNop())
+			app.Go("worker", func(ctx context.Context) error {
+				if tt.failing {
+					return errBoom
+				}
+				<-ctx.Done()
+				if tt.stuck {
+					time.Sleep(time.Second)
+				}
+				record("stop worker")
+				return ctx.Err()
+			})
+			app.Go("http", func(ctx context.Context) error {
+				<-ctx.Done()
+				record("stop http")
+				return nil
+			})
+			app.OnStop("storage", func(context.Context) error {
+				record("hook storage")
+				return tt.hookErr
+			})
+			app.OnStop("logger", func(context.Context) error {
+				record("hook logger")
+				return nil
+			})
+
+			if !tt.failing {
+				cancel()
+			}
+			err := app.Run(ctx)
+			cancel()
+
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("got %v, want nil", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("got %v, want %s", err, tt.wantErr)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if !reflect.DeepEqual(order, tt.wantOrder) {
+				t.Errorf("got %v, want %v", order, tt.wantOrder)
+			}
+		})
+	}
+}
+
+func TestLifecycle_RunHookAfter(t *testing.T) {
+	var closed []string
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	app := New(100*time.Millisecond, zap.NewNop())
+	stuck := make(chan struct{})
+	defer close(stuck)
+	app.Go("worker", func(ctx context.Context) error {
+		<-stuck
+		return nil
+	})
+	app.Go("http", func(ctx context.Context) error {
+		<-ctx.Done()
+		return nil
+	})
+	app.OnStop("storage", func(context.Context) error {
+		closed = append(closed, "storage")
+		return nil
+	}, "worker")
+	app.OnStop("tracing", func(context.Context) error {
+		closed = append(closed, "tracing")
+		return nil
+	}, "http")
+
+	err := app.Run(ctx)
+	if err == nil || !strings.Contains(err.Error(), "worker: not stopped in") {
+		t.Errorf("got %v, want worker not stopped", err)
+	}
+	if !reflect.DeepEqual(closed, []string{"tracing"}) {
+		t.Errorf("got %v, want only tracing hook", closed)
+	}
+}
+
+func TestStopContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	app := New(time.Second, zap.NewNop())
+	var http, worker time.Time
+	app.Go("worker", func(ctx context.Context) error {
+		<-ctx.Done()
+		stopCtx, cancel := StopContext(ctx, time.Hour)
+		defer cancel()
+		worker, _ = stopCtx.Deadline()
+		return nil
+	})
+	app.Go("http", func(ctx context.Context) error {
+		<-ctx.Done()
+		// slow drain takes time of next components
+		time.Sleep(50 * time.Millisecond)
+		stopCtx, cancel := StopContext(ctx, time.Hour)
+		defer cancel()
+		http, _ = stopCtx.Deadline()
+		return nil
+	})
+	if err := app.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+	// deadline is set when stop starts, not after drain of http
+	if !http.Equal(worker) || http.After(time.Now().Add(950*time.Millisecond)) {
+		t.Errorf("got deadlines %v and %v, want the same one in a second since stop", http, worker)
+	}
+
+	stopCtx, stop := StopContext(context.Background(), time.Minute)
+	defer stop()
+	if d, _ := stopCtx.Deadline(); time.Until(d) > time.Minute {
+		t.Errorf("got deadline %v, want in a minute", d)
+	}
+}
@@ -0,0 +1,110 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"goTSVParser/config"
+	"time"
+)
+
+const (
+	defaultSampleInitial    = 100
+	defaultSampleThereafter = 100
+)
+
+// New build logger from config, json to stderr on info level by default, messages are not sampled.
+// Returned level changes level of running logger, see SetLevel
+func New(cfg config.Log) (*zap.Logger, zap.AtomicLevel, error) {
+	level := zap.NewAtomicLevel()
+	if err := SetLevel(level, cfg.Level); err != nil {
+		return nil, level, err
+	}
+
+	zc := zap.NewProductionConfig()
+	zc.Level = level
+	zc.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+	zc.EncoderConfig.EncodeDuration = zapcore.StringDurationEncoder
+	switch cfg.Format {
+	case "", "json":
+	case "console":
+		zc.Encoding = "console"
+		zc.EncoderConfig.EncodeLevel = zapcore.CapitalLevelEncoder
+	default:
+		return nil, level, fmt.Errorf("bad log format %s, want json or console", cfg.Format)
+	}
+	if cfg.Output != "" {
+		zc.OutputPaths = []string{cfg.Output}
+	}
+
+	zc.Sampling = nil
+	logger, err := zc.Build()
+	return logger, level, err
+}
+
+// Sampled wrap logger of per-row messages, per second first messages with the same text are logged,
+// then every thereafter one, so they don't flood the log
+func Sampled(logger *zap.Logger, cfg config.Log) *zap.Logger {
+	initial, thereafter := cfg.SampleInitial, cfg.SampleThereafter
+	if initial <= 0 {
+		initial = defaultSampleInitial
+	}
+	if thereafter <= 0 {
+		thereafter = defaultSampleThereafter
+	}
+	return logger.WithOptions(zap.WrapCore(func(c zapcore.Core) zapcore.Core {
+		return zapcore.NewSamplerWithOptions(c, time.Second, initial, thereafter)
+	}))
+}
+
+// SetLevel set level by name, info when name is empty
+func SetLevel(level zap.AtomicLevel, name string) error {
+	if name == "" {
+		level.SetLevel(zap.InfoLevel)
+		return nil
+	}
+	l, err := zapcore.ParseLevel(name)
+	if err != nil {
+		return fmt.Errorf("bad log level: %w", err)
+	}
+	level.SetLevel(l)
+	return nil
+}
+
+type ctxKey struct{}
+
+// With return ctx carrying logger, e.g. with request id of http request
+func With(ctx context.Context, l *zap.Logger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, l)
+}
+
+// From return logger of ctx or fallback when ctx has none
+func From(ctx context.Context, fallback *zap.Logger) *zap.Logger {
+	if l, ok := ctx.Value(ctxKey{}).(*zap.Logger); ok {
+		return l
+	}
+	return fallback
+}
+
+// fields used by all components, so logs can be filtered the same way
+
+func File(path string) zap.Field {
+	return zap.String("file", path)
+}
+
+func UnitGUID(guid string) zap.Field {
+	return zap.String("unit_guid", guid)
+}
+
+func Stage(stage string) zap.Field {
+	return zap.String("stage", stage)
+}
+
+func Duration(d time.Duration) zap.Field {
+	return zap.Duration("duration", d)
+}
+
+func RequestID(id string) zap.Field {
+	return zap.String("request_id", id)
+}
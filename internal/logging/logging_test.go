@@ -0,0 +1,86 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"go.uber.org/zap"
+	"goTSVParser/config"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config.Log
+		wantErr bool
+	}{
+		{name: "OK#1", cfg: config.Log{}},
+		{name: "OK#2", cfg: config.Log{Level: "debug", Format: "console", Output: "stdout"}},
+		{name: "BAD#1", cfg: config.Log{Level: "verbose"}, wantErr: true},
+		{name: "BAD#2", cfg: config.Log{Format: "xml"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := New(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("got %v, want err %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSampled(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "log.json")
+	cfg := config.Log{Output: out, SampleInitial: 10, SampleThereafter: 50}
+	logger, _, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := Sampled(logger, cfg)
+	for i := 0; i < 110; i++ {
+		rows.Info("row skipped", zap.Int("line", i))
+		logger.Info("request", zap.Int("n", i))
+	}
+	logger.Info("file processed")
+	logger.Sync()
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 10 first rows, 50th and 100th after them, every request and other message
+	if got := bytes.Count(data, []byte("\n")); got != 13+110 {
+		t.Errorf("got %d lines, want %d", got, 13+110)
+	}
+}
+
+func TestFrom(t *testing.T) {
+	fallback, l := zap.NewNop(), zap.NewExample()
+	if From(context.Background(), fallback) != fallback {
+		t.Errorf("want fallback without logger in ctx")
+	}
+	if From(With(context.Background(), l), fallback) != l {
+		t.Errorf("want logger of ctx")
+	}
+}
+
+func TestSetLevel(t *testing.T) {
+	logger, level, err := New(config.Log{Level: "warn", Output: filepath.Join(t.TempDir(), "log.json")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if logger.Core().Enabled(zap.InfoLevel) {
+		t.Errorf("info is enabled on warn level")
+	}
+	if err := SetLevel(level, "debug"); err != nil {
+		t.Fatal(err)
+	}
+	if !logger.Core().Enabled(zap.DebugLevel) {
+		t.Errorf("debug is not enabled after change of level")
+	}
+	if err := SetLevel(level, "verbose"); err == nil || level.Level() != zap.DebugLevel {
+		t.Errorf("bad level is applied")
+	}
+}
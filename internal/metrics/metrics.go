@@ -0,0 +1,81 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"time"
+)
+
+// stages of pipeline where file can fail
+const (
+	StageUpload = "upload"
+	StageParse  = "parse"
+	StageSave   = "save"
+	StageRender = "render"
+)
+
+// Registry of all metrics, served on /metrics
+var Registry = prometheus.NewRegistry()
+
+var factory = promauto.With(Registry)
+
+var (
+	FilesDiscovered = factory.NewCounter(prometheus.CounterOpts{
+		Name: "tsv_files_discovered_total",
+		Help: "Files found by watcher and sent to worker.",
+	})
+	RowsParsed = factory.NewCounter(prometheus.CounterOpts{
+		Name: "tsv_rows_parsed_total",
+		Help: "Rows read by parser.",
+	})
+	RowsDropped = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "tsv_rows_dropped_total",
+		Help: "Rows skipped by parser filter rules.",
+	}, []string{"rule"})
+	RowErrors = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "tsv_row_errors_total",
+		Help: "Invalid fields of rows skipped by parser validation.",
+	}, []string{"field"})
+	RowsSaved = factory.NewCounter(prometheus.CounterOpts{
+		Name: "tsv_rows_saved_total",
+		Help: "Rows saved into db.",
+	})
+	SaveDuration = factory.NewHistogram(prometheus.HistogramOpts{
+		Name:    "tsv_save_duration_seconds",
+		Help:    "Latency of saving batch of rows into db.",
+		Buckets: prometheus.ExponentialBuckets(0.0005, 2, 14),
+	})
+	RenderDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "tsv_render_duration_seconds",
+		Help:    "Duration of rendering report of one unit.",
+		Buckets: prometheus.ExponentialBuckets(0.001, 2, 16),
+	}, []string{"format"})
+	FilesFailed = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "tsv_files_failed_total",
+		Help: "Files failed by pipeline stage.",
+	}, []string{"stage"})
+
+	HTTPRequests = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "HTTP requests by method, route and status code.",
+	}, []string{"method", "route", "code"})
+	HTTPDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Duration of HTTP requests by method and route.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route"})
+)
+
+func init() {
+	Registry.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+	// failures are exported as zero before first one happens
+	for _, stage := range []string{StageUpload, StageParse, StageSave, StageRender} {
+		FilesFailed.WithLabelValues(stage)
+	}
+}
+
+// Since observe seconds passed from start
+func Since(o prometheus.Observer, start time.Time) {
+	o.Observe(time.Since(start).Seconds())
+}
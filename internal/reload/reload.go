@@ -0,0 +1,140 @@
+package reload
+
+import (
+	"context"
+	"errors"
+	"go.uber.org/zap"
+	"goTSVParser/config"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// live keys are applied to running components, changes of other keys need restart.
+// Service reads svg_gen, dir_from and limits.max_* of reloaded config on every use
+var live = map[string]bool{
+	"refresh_interval":       true,
+	"dir_from":               true,
+	"dir_to":                 true,
+	"svg_gen":                true,
+	"limits.max_page_size":   true,
+	"limits.max_report_rows": true,
+	"log.level":              true,
+}
+
+const pollInterval = 5 * time.Second
+
+// Reloader load config again on SIGHUP and when config file is changed
+type Reloader struct {
+	mu      sync.Mutex
+	load    func() (config.Config, error)
+	started config.Config
+	current config.Config
+	apply   []func(config.Config) error
+	logger  *zap.Logger
+
+	poll     time.Duration
+	modTime  time.Time
+	fileSize int64
+}
+
+// New reloader of config which process is started with, load must return validated config
+func New(started config.Config, load func() (config.Config, error), logger *zap.Logger) *Reloader {
+	r := &Reloader{load: load, started: started, current: started, logger: logger, poll: pollInterval}
+	r.modTime, r.fileSize = r.stat()
+	return r
+}
+
+// OnReload add function applying live keys of new config, it is called when any of them is changed
+func (r *Reloader) OnReload(apply func(config.Config) error) {
+	r.apply = append(r.apply, apply)
+}
+
+// Reload load config and apply live changes, keys changed since start which need restart are returned.
+// Invalid config is not applied
+func (r *Reloader) Reload() (applied []string, restart []string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	next, err := r.load()
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, key := range config.Changed(r.current, next) {
+		if live[key] {
+			applied = append(applied, key)
+		}
+	}
+	for _, key := range config.Changed(r.started, next) {
+		if !live[key] {
+			restart = append(restart, key)
+		}
+	}
+
+	var errs []error
+	if len(applied) > 0 {
+		for _, apply := range r.apply {
+			errs = append(errs, apply(next))
+		}
+	}
+	r.current = next
+	return applied, restart, errors.Join(errs...)
+}
+
+// Run reload config until ctx is done
+func (r *Reloader) Run(ctx context.Context) error {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	ticker := time.NewTicker(r.poll)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-hup:
+			r.logger.Info("reloading config on SIGHUP")
+			r.reload()
+		case <-ticker.C:
+			modTime, size := r.stat()
+			if modTime.Equal(r.modTime) && size == r.fileSize {
+				continue
+			}
+			r.modTime, r.fileSize = modTime, size
+			r.logger.Info("reloading changed config file", zap.String("file", r.started.CFile))
+			r.reload()
+		}
+	}
+}
+
+func (r *Reloader) reload() {
+	applied, restart, err := r.Reload()
+	if err != nil {
+		r.logger.Error("failed to reload config", zap.Error(err))
+		return
+	}
+	if len(applied) > 0 {
+		r.logger.Info("config reloaded", zap.Strings("applied", applied))
+	}
+	if len(restart) > 0 {
+		r.logger.Warn("config changes need restart", zap.Strings("keys", restart))
+	}
+	if len(applied) == 0 && len(restart) == 0 {
+		r.logger.Info("config is not changed")
+	}
+}
+
+// stat of config file, zero when there is no file
+func (r *Reloader) stat() (time.Time, int64) {
+	if r.started.CFile == "" {
+		return time.Time{}, 0
+	}
+	info, err := os.Stat(r.started.CFile)
+	if err != nil {
+		return time.Time{}, 0
+	}
+	return info.ModTime(), info.Size()
+}
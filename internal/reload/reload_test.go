@@ -0,0 +1,132 @@
+package reload
+
+import (
+	"context"
+	"errors"
+	"go.uber.org/zap"
+	"goTSVParser/config"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestReloader_Reload(t *testing.T) {
+	started := config.Config{Host: "localhost:8080", RefreshInterval: 10, DirectoryFrom: "from"}
+	tests := []struct {
+		name        string
+		next        config.Config
+		loadErr     error
+		wantApplied []string
+		wantRestart []string
+		wantErr     bool
+	}{
+		{name: "OK#1", next: started},
+		{name: "OK#2", next: config.Config{Host: "localhost:8080", RefreshInterval: 1, DirectoryFrom: "from", Log: config.Log{Level: "debug"}},
+			wantApplied: []string{"refresh_interval", "log.level"}},
+		{name: "OK#3", next: config.Config{Host: "0.0.0.0:8081", RefreshInterval: 10, DirectoryFrom: "to", Auth: config.Auth{Issuer: "sso"}},
+			wantApplied: []string{"dir_from"}, wantRestart: []string{"host", "auth.issuer"}},
+		{name: "BAD#1", loadErr: errors.New("dsn is required"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []config.Config
+			r := New(started, func() (config.Config, error) { return tt.next, tt.loadErr }, zap.NewNop())
+			r.OnReload(func(c config.Config) error {
+				got = append(got, c)
+				return nil
+			})
+
+			applied, restart, err := r.Reload()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got %v, want err %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(applied, tt.wantApplied) || !reflect.DeepEqual(restart, tt.wantRestart) {
+				t.Errorf("got applied %v and restart %v, want %v and %v", applied, restart, tt.wantApplied, tt.wantRestart)
+			}
+			if (len(got) > 0) != (len(tt.wantApplied) > 0) {
+				t.Errorf("config is applied %d times, want on live changes only", len(got))
+			}
+		})
+	}
+}
+
+func TestReloader_ReloadLive(t *testing.T) {
+	started := config.Config{RefreshInterval: 10, DirectoryFrom: "from", DirectoryTo: "to"}
+	tests := []struct {
+		key    string
+		change func(c *config.Config)
+		live   bool
+	}{
+		{key: "refresh_interval", change: func(c *config.Config) { c.RefreshInterval = 1 }, live: true},
+		{key: "dir_from", change: func(c *config.Config) { c.DirectoryFrom = "new" }, live: true},
+		{key: "dir_to", change: func(c *config.Config) { c.DirectoryTo = "out" }, live: true},
+		{key: "svg_gen", change: func(c *config.Config) { c.SvgGen = true }, live: true},
+		{key: "limits.max_page_size", change: func(c *config.Config) { c.Limits.MaxPageSize = 10 }, live: true},
+		{key: "limits.max_report_rows", change: func(c *config.Config) { c.Limits.MaxReportRows = 10 }, live: true},
+		{key: "log.level", change: func(c *config.Config) { c.Log.Level = "debug" }, live: true},
+		{key: "limits.burst", change: func(c *config.Config) { c.Limits.Burst = 5 }},
+		{key: "limits.max_upload_bytes", change: func(c *config.Config) { c.Limits.MaxUploadBytes = 5 }},
+		{key: "log.format", change: func(c *config.Config) { c.Log.Format = "console" }},
+	}
+	tested := make(map[string]bool)
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			tested[tt.key] = true
+			next := started
+			tt.change(&next)
+			r := New(started, func() (config.Config, error) { return next, nil }, zap.NewNop())
+
+			applied, restart, err := r.Reload()
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := []string{tt.key}
+			if tt.live && (!reflect.DeepEqual(applied, want) || restart != nil) {
+				t.Errorf("got applied %v and restart %v, want applied %v only", applied, restart, want)
+			}
+			if !tt.live && (applied != nil || !reflect.DeepEqual(restart, want)) {
+				t.Errorf("got applied %v and restart %v, want restart %v only", applied, restart, want)
+			}
+		})
+	}
+	for key := range live {
+		if !tested[key] {
+			t.Errorf("live key %s is not tested", key)
+		}
+	}
+}
+
+func TestReloader_Run(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(file, []byte(`{"refresh_interval": 10}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	applied := make(chan config.Config, 1)
+	r := New(config.Config{RefreshInterval: 10, CFile: file}, func() (config.Config, error) {
+		return config.Config{RefreshInterval: 1, CFile: file}, nil
+	}, zap.NewNop())
+	r.poll = 10 * time.Millisecond
+	r.OnReload(func(c config.Config) error {
+		applied <- c
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go r.Run(ctx)
+
+	if err := os.WriteFile(file, []byte(`{"refresh_interval": 1}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case c := <-applied:
+		if c.RefreshInterval != 1 {
+			t.Errorf("got interval %d, want 1", c.RefreshInterval)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("changed config file is not reloaded")
+	}
+}
@@ -0,0 +1,37 @@
+package service
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"goTSVParser/internal/shema"
+)
+
+// GetUnits get page of known unit guids
+func (s *Service) GetUnits(ctx context.Context, f shema.CatalogFilter) (shema.Page[shema.CatalogItem], error) {
+	return s.catalog(ctx, "UnitGUID", f)
+}
+
+// GetInventory get page of known inventory ids
+func (s *Service) GetInventory(ctx context.Context, f shema.CatalogFilter) (shema.Page[shema.CatalogItem], error) {
+	return s.catalog(ctx, "InventoryID", f)
+}
+
+func (s *Service) catalog(ctx context.Context, field string, f shema.CatalogFilter) (shema.Page[shema.CatalogItem], error) {
+	const op = "service.catalog"
+
+	if err := s.pageBounds(&f.Page, &f.Limit); err != nil {
+		return shema.Page[shema.CatalogItem]{}, err
+	}
+
+	items, total, err := s.storage.GetCatalog(ctx, field, f)
+	if err != nil {
+		s.log(ctx).Info("request failed", zap.String("op", op), zap.Error(err))
+		return shema.Page[shema.CatalogItem]{}, err
+	}
+	return shema.Page[shema.CatalogItem]{
+		Items:    items,
+		Total:    total,
+		Page:     f.Page,
+		PageSize: f.Limit,
+	}, nil
+}
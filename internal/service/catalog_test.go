@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+	"goTSVParser/internal/constants"
+	"goTSVParser/internal/domains/mocks"
+	"goTSVParser/internal/shema"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestService_GetUnits(t *testing.T) {
+	seen := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	unit := shema.CatalogItem{
+		ID:        "01749246-95f6-57db-b7c3-2ae0e8be671f",
+		Rows:      3,
+		FirstFile: "from/a.tsv",
+		LastFile:  "from/b.tsv",
+		FirstSeen: seen,
+		LastSeen:  seen.Add(time.Hour),
+	}
+	tests := []struct {
+		name        string
+		args        shema.CatalogFilter
+		storageMock storageMock[shema.CatalogFilter]
+		wantPage    shema.Page[shema.CatalogItem]
+		wantErr     error
+	}{
+		{
+			name: "OK1",
+			args: shema.CatalogFilter{Query: "0174"},
+			storageMock: func(c *mocks.Storage, f shema.CatalogFilter) {
+				c.Mock.On("GetCatalog", mock.Anything, "UnitGUID", shema.CatalogFilter{Query: "0174", Page: 1, Limit: defaultPageSize}).
+					Return([]shema.CatalogItem{unit}, 1, nil).Times(1)
+			},
+			wantPage: shema.Page[shema.CatalogItem]{
+				Items:    []shema.CatalogItem{unit},
+				Total:    1,
+				Page:     1,
+				PageSize: defaultPageSize,
+			},
+		},
+		{
+			name:        "BAD1",
+			args:        shema.CatalogFilter{Page: -1},
+			storageMock: func(c *mocks.Storage, f shema.CatalogFilter) {},
+			wantErr:     constants.ErrInvalidRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := mocks.NewStorage(t)
+			tt.storageMock(storage, tt.args)
+			logger, _ := zap.NewProduction()
+			service := Service{
+				storage: storage,
+				logger:  logger,
+			}
+
+			page, err := service.GetUnits(context.Background(), tt.args)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(page, tt.wantPage) {
+				t.Errorf("got %v, want %v", page, tt.wantPage)
+			}
+		})
+	}
+}
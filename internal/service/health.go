@@ -0,0 +1,48 @@
+package service
+
+import (
+	"context"
+	"goTSVParser/internal/shema"
+	"time"
+)
+
+// db ping of readiness check is cancelled after this
+const healthTimeout = 2 * time.Second
+
+// workerErr remember last failure of worker for health checks
+func (s *Service) workerErr(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastErr, s.lastErrTime = err, time.Now()
+}
+
+// Liveness check that watcher is running, db is not checked, so restart doesn't help with db outage
+func (s *Service) Liveness(ctx context.Context) shema.Health {
+	h := shema.Health{Watcher: s.watcher.Health(), Worker: s.workerHealth()}
+	h.Status = h.Watcher.Status
+	return h
+}
+
+// Readiness check watcher and db connection
+func (s *Service) Readiness(ctx context.Context) shema.Health {
+	h := s.Liveness(ctx)
+
+	ctx, cancel := context.WithTimeout(ctx, healthTimeout)
+	defer cancel()
+	h.DB = &shema.DBHealth{Status: shema.HealthOK}
+	if err := s.storage.CheckConnection(ctx); err != nil {
+		h.DB.Status, h.DB.Error = shema.HealthFail, err.Error()
+		h.Status = shema.HealthFail
+	}
+	return h
+}
+
+func (s *Service) workerHealth() shema.WorkerHealth {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.lastErr == nil {
+		return shema.WorkerHealth{}
+	}
+	at := s.lastErrTime
+	return shema.WorkerHealth{LastError: s.lastErr.Error(), LastErrorAt: &at}
+}
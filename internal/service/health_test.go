@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+	"goTSVParser/config"
+	"goTSVParser/internal/domains/mocks"
+	"goTSVParser/internal/shema"
+	"goTSVParser/internal/workers"
+	"testing"
+)
+
+func TestService_Readiness(t *testing.T) {
+	tests := []struct {
+		name          string
+		started       bool
+		workerErr     error
+		storageMock   storageMock[struct{}]
+		wantStatus    string
+		wantDB        string
+		wantWatcher   string
+		wantLastError string
+	}{
+		{
+			name:    "OK1",
+			started: true,
+			storageMock: func(c *mocks.Storage, _ struct{}) {
+				c.Mock.On("CheckConnection", mock.Anything).Return(nil).Times(1)
+			},
+			wantStatus:  shema.HealthOK,
+			wantDB:      shema.HealthOK,
+			wantWatcher: shema.HealthOK,
+		},
+		{
+			name:      "OK2",
+			started:   true,
+			workerErr: errors.New("failed to render a.tsv"),
+			storageMock: func(c *mocks.Storage, _ struct{}) {
+				c.Mock.On("CheckConnection", mock.Anything).Return(nil).Times(1)
+			},
+			wantStatus:    shema.HealthOK,
+			wantDB:        shema.HealthOK,
+			wantWatcher:   shema.HealthOK,
+			wantLastError: "failed to render a.tsv",
+		},
+		{
+			name:    "BAD1",
+			started: true,
+			storageMock: func(c *mocks.Storage, _ struct{}) {
+				c.Mock.On("CheckConnection", mock.Anything).Return(errors.New("connection refused")).Times(1)
+			},
+			wantStatus:  shema.HealthFail,
+			wantDB:      shema.HealthFail,
+			wantWatcher: shema.HealthOK,
+		},
+		{
+			name: "BAD2",
+			storageMock: func(c *mocks.Storage, _ struct{}) {
+				c.Mock.On("CheckConnection", mock.Anything).Return(nil).Times(1)
+			},
+			wantStatus:  shema.HealthFail,
+			wantDB:      shema.HealthOK,
+			wantWatcher: shema.HealthFail,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := mocks.NewStorage(t)
+			tt.storageMock(storage, struct{}{})
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			watcher := workers.NewWatcher(config.Config{RefreshInterval: 60, DirectoryFrom: t.TempDir()}, zap.NewNop())
+			if tt.started {
+				watcher.Scan(ctx, make(chan workers.Found))
+			}
+			service := Service{storage: storage, watcher: watcher}
+			if tt.workerErr != nil {
+				service.workerErr(tt.workerErr)
+			}
+
+			h := service.Readiness(ctx)
+			if h.Status != tt.wantStatus {
+				t.Errorf("got status %s, want %s", h.Status, tt.wantStatus)
+			}
+			if h.DB == nil || h.DB.Status != tt.wantDB {
+				t.Errorf("got db %+v, want %s", h.DB, tt.wantDB)
+			}
+			if h.Watcher.Status != tt.wantWatcher {
+				t.Errorf("got watcher %+v, want %s", h.Watcher, tt.wantWatcher)
+			}
+			if h.Worker.LastError != tt.wantLastError {
+				t.Errorf("got last error %q, want %q", h.Worker.LastError, tt.wantLastError)
+			}
+		})
+	}
+}
+
+func TestService_Liveness(t *testing.T) {
+	// db isn't checked, storage mock fails on unexpected call
+	service := Service{storage: mocks.NewStorage(t), watcher: workers.NewWatcher(config.Config{RefreshInterval: 60}, zap.NewNop())}
+	h := service.Liveness(context.Background())
+	if h.Status != shema.HealthFail || h.DB != nil {
+		t.Errorf("got %+v, want fail of not started watcher without db", h)
+	}
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"goTSVParser/internal/constants"
+	"goTSVParser/internal/shema"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+var jobID = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+var jobStatuses = map[string]bool{
+	shema.JobPending:  true,
+	shema.JobParsing:  true,
+	shema.JobStored:   true,
+	shema.JobRendered: true,
+	shema.JobFailed:   true,
+}
+
+// GetJob get status and generated outputs of ingested file
+func (s *Service) GetJob(ctx context.Context, id string) (shema.Job, error) {
+	const op = "service.GetJob"
+
+	if !jobID.MatchString(id) {
+		return shema.Job{}, constants.ErrNotFound
+	}
+
+	job, err := s.storage.GetJob(ctx, id)
+	if err != nil {
+		s.log(ctx).Info("request failed", zap.String("op", op), zap.Error(err))
+		return shema.Job{}, err
+	}
+	job.Outputs = s.outputs(job)
+	return job, nil
+}
+
+// GetJobs get page of ingested files
+func (s *Service) GetJobs(ctx context.Context, f shema.JobFilter) (shema.Page[shema.Job], error) {
+	const op = "service.GetJobs"
+
+	if f.Status != "" && !jobStatuses[f.Status] {
+		return shema.Page[shema.Job]{}, &constants.ValidationError{Field: "status", Reason: "is unknown"}
+	}
+	if err := s.pageBounds(&f.Page, &f.Limit); err != nil {
+		return shema.Page[shema.Job]{}, err
+	}
+
+	jobs, total, err := s.storage.GetJobs(ctx, f)
+	if err != nil {
+		s.log(ctx).Info("request failed", zap.String("op", op), zap.Error(err))
+		return shema.Page[shema.Job]{}, err
+	}
+	for i := range jobs {
+		jobs[i].Outputs = s.outputs(jobs[i])
+	}
+
+	return shema.Page[shema.Job]{
+		Items:    jobs,
+		Total:    total,
+		Page:     f.Page,
+		PageSize: f.Limit,
+	}, nil
+}
+
+// outputs list reports written for units of job
+func (s *Service) outputs(job shema.Job) []string {
+	if job.Status != shema.JobRendered {
+		return nil
+	}
+	dir := s.writer.OutputDir(job.File)
+	var result []string
+	for _, guid := range job.UnitGUIDs {
+		matches, _ := filepath.Glob(filepath.Join(dir, guid+".*"))
+		for _, m := range matches {
+			if info, err := os.Stat(m); err == nil && !info.IsDir() {
+				result = append(result, m)
+			}
+		}
+	}
+	return result
+}
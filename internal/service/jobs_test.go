@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+	"goTSVParser/config"
+	"goTSVParser/internal/constants"
+	"goTSVParser/internal/domains/mocks"
+	"goTSVParser/internal/shema"
+	"goTSVParser/internal/workers"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestService_GetJob(t *testing.T) {
+	const id = "8f1d2a0c6b7e4f3a9c5d1e2f3a4b5c6d"
+	const guid = "01749246-9617-585e-9e19-157ccad61ee2"
+	tests := []struct {
+		name        string
+		id          string
+		status      string
+		outputs     []string
+		storageMock storageMock[shema.Job]
+		wantOutputs int
+		wantErr     error
+	}{
+		{
+			name:   "PENDING",
+			id:     id,
+			status: shema.JobPending,
+			storageMock: func(c *mocks.Storage, job shema.Job) {
+				c.Mock.On("GetJob", mock.Anything, id).Return(job, nil).Times(1)
+			},
+		},
+		{
+			name:    "RENDERED",
+			id:      id,
+			status:  shema.JobRendered,
+			outputs: []string{guid + ".pdf", "other.pdf"},
+			storageMock: func(c *mocks.Storage, job shema.Job) {
+				c.Mock.On("GetJob", mock.Anything, id).Return(job, nil).Times(1)
+			},
+			wantOutputs: 1,
+		},
+		{
+			name: "BAD1",
+			id:   "../../etc",
+			storageMock: func(c *mocks.Storage, job shema.Job) {
+			},
+			wantErr: constants.ErrNotFound,
+		},
+		{
+			name: "BAD2",
+			id:   "0f1d2a0c6b7e4f3a9c5d1e2f3a4b5c6d",
+			storageMock: func(c *mocks.Storage, job shema.Job) {
+				c.Mock.On("GetJob", mock.Anything, "0f1d2a0c6b7e4f3a9c5d1e2f3a4b5c6d").Return(shema.Job{}, constants.ErrNotFound).Times(1)
+			},
+			wantErr: constants.ErrNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dirFrom, dirTo := t.TempDir(), t.TempDir()
+			file := filepath.Join(dirFrom, uploadDir, id, "a.tsv")
+			cfg := config.Config{DirectoryFrom: dirFrom, DirectoryTo: dirTo}
+			writer := workers.NewWriter(cfg)
+			for _, o := range tt.outputs {
+				os.MkdirAll(writer.OutputDir(file), 0755)
+				os.WriteFile(filepath.Join(writer.OutputDir(file), o), nil, 0644)
+			}
+
+			storage := mocks.NewStorage(t)
+			tt.storageMock(storage, shema.Job{ID: id, File: file, Status: tt.status, UnitGUIDs: []string{guid}})
+			logger, _ := zap.NewProduction()
+			service := Service{
+				storage: storage,
+				writer:  writer,
+				config:  cfg,
+				logger:  logger,
+			}
+
+			job, err := service.GetJob(context.Background(), tt.id)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got %v, want %v", err, tt.wantErr)
+			}
+			if job.Status != tt.status {
+				t.Errorf("got status %s, want %s", job.Status, tt.status)
+			}
+			if len(job.Outputs) != tt.wantOutputs {
+				t.Errorf("got outputs %v, want %d", job.Outputs, tt.wantOutputs)
+			}
+		})
+	}
+}
+
+func TestService_GetJobs(t *testing.T) {
+	tests := []struct {
+		name        string
+		filter      shema.JobFilter
+		storageMock storageMock[shema.JobFilter]
+		wantPage    shema.Page[shema.Job]
+		wantErr     error
+	}{
+		{
+			name:   "OK1",
+			filter: shema.JobFilter{Status: shema.JobFailed},
+			storageMock: func(c *mocks.Storage, f shema.JobFilter) {
+				c.Mock.On("GetJobs", mock.Anything, shema.JobFilter{Status: shema.JobFailed, Page: 1, Limit: defaultPageSize}).
+					Return([]shema.Job{{ID: "1", Status: shema.JobFailed}}, 21, nil).Times(1)
+			},
+			wantPage: shema.Page[shema.Job]{
+				Items:    []shema.Job{{ID: "1", Status: shema.JobFailed}},
+				Total:    21,
+				Page:     1,
+				PageSize: defaultPageSize,
+			},
+		},
+		{
+			name:        "BAD1",
+			filter:      shema.JobFilter{Status: "done"},
+			storageMock: func(c *mocks.Storage, f shema.JobFilter) {},
+			wantErr:     constants.ErrInvalidRequest,
+		},
+		{
+			name:        "BAD2",
+			filter:      shema.JobFilter{Limit: defaultMaxPage + 1},
+			storageMock: func(c *mocks.Storage, f shema.JobFilter) {},
+			wantErr:     constants.ErrInvalidRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := mocks.NewStorage(t)
+			tt.storageMock(storage, tt.filter)
+			logger, _ := zap.NewProduction()
+			service := Service{
+				storage: storage,
+				logger:  logger,
+			}
+
+			page, err := service.GetJobs(context.Background(), tt.filter)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(page, tt.wantPage) {
+				t.Errorf("got %v, want %v", page, tt.wantPage)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"go.uber.org/zap"
+	"goTSVParser/internal/constants"
+	"goTSVParser/internal/shema"
+	"goTSVParser/internal/workers"
+)
+
+// schemaOf find schema by name, occurrence is one of them
+func (s *Service) schemaOf(name string) (*workers.Schema, error) {
+	for _, schema := range s.parser.Schemas() {
+		if schema.Name == name {
+			return schema, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: unknown schema %q", constants.ErrBadRequest, name)
+}
+
+// GetSchemas list schemas of files, occurrence is the last one
+func (s *Service) GetSchemas(ctx context.Context) []shema.SchemaInfo {
+	var result []shema.SchemaInfo
+	for _, schema := range s.parser.Schemas() {
+		result = append(result, shema.SchemaInfo{Name: schema.Name, Key: schema.Key, Columns: schema.Names()})
+	}
+	return result
+}
+
+// GetRecords get page of rows of key of schema, row is object of columns with id and file
+func (s *Service) GetRecords(ctx context.Context, schemaName string, key string, r shema.RecordRequest) (shema.Page[map[string]interface{}], error) {
+	const op = "service.GetRecords"
+
+	schema, err := s.schemaOf(schemaName)
+	if err != nil {
+		return shema.Page[map[string]interface{}]{}, err
+	}
+	if err := s.pageBounds(&r.Page, &r.Limit); err != nil {
+		return shema.Page[map[string]interface{}]{}, err
+	}
+
+	records, total, err := s.storage.GetRecordsPage(ctx, schema.Schema, key, r.Limit, (r.Page-1)*r.Limit)
+	if err != nil {
+		s.log(ctx).Info("request failed", zap.String("op", op), zap.Error(err))
+		return shema.Page[map[string]interface{}]{}, err
+	}
+	if total == 0 {
+		return shema.Page[map[string]interface{}]{}, constants.ErrNotFound
+	}
+
+	items := make([]map[string]interface{}, len(records))
+	for i, record := range records {
+		item := map[string]interface{}{"id": record.ID, "file": record.File}
+		for j, name := range schema.Names() {
+			item[name] = record.Values[j]
+		}
+		items[i] = item
+	}
+	return shema.Page[map[string]interface{}]{
+		Items:    items,
+		Total:    total,
+		Page:     r.Page,
+		PageSize: r.Limit,
+	}, nil
+}
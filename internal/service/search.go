@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"go.uber.org/zap"
+	"goTSVParser/internal/constants"
+	"goTSVParser/internal/shema"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Search get page of data filtered by any field, with full-text search in message text, sorting and projection
+func (s *Service) Search(ctx context.Context, r shema.SearchRequest) (shema.Page[map[string]interface{}], error) {
+	const op = "service.Search"
+
+	q, fields, err := s.searchQuery(r)
+	if err != nil {
+		return shema.Page[map[string]interface{}]{}, err
+	}
+
+	tsvFromDB, total, err := s.storage.GetOccurrences(ctx, q)
+	if err != nil {
+		s.log(ctx).Info("request failed", zap.String("op", op), zap.Error(err))
+		return shema.Page[map[string]interface{}]{}, err
+	}
+
+	items := make([]map[string]interface{}, 0, len(tsvFromDB))
+	for _, t := range tsvFromDB {
+		items = append(items, project(t, fields))
+	}
+	return shema.Page[map[string]interface{}]{
+		Items:    items,
+		Total:    total,
+		Page:     q.Offset/q.Limit + 1,
+		PageSize: q.Limit,
+	}, nil
+}
+
+func (s *Service) searchQuery(r shema.SearchRequest) (shema.OccurrenceQuery, []string, error) {
+	if err := s.pageBounds(&r.Page, &r.Limit); err != nil {
+		return shema.OccurrenceQuery{}, nil, err
+	}
+	if r.LevelMin != nil && r.LevelMax != nil && *r.LevelMin > *r.LevelMax {
+		return shema.OccurrenceQuery{}, nil, &constants.ValidationError{Field: "level_min", Reason: "is greater than level_max"}
+	}
+
+	q := shema.OccurrenceQuery{
+		Filters:         map[string]string{},
+		LevelMin:        r.LevelMin,
+		LevelMax:        r.LevelMax,
+		MessageIDPrefix: r.MessageIDPrefix,
+		Text:            r.Query,
+		Limit:           r.Limit,
+		Offset:          (r.Page - 1) * r.Limit,
+	}
+	for name, value := range r.Filters {
+		field, ok := shema.TsvField(name)
+		if !ok || field == "ID" {
+			return shema.OccurrenceQuery{}, nil, &constants.ValidationError{Field: name, Reason: "is unknown filter"}
+		}
+		value, err := filterValue(field, value)
+		if err != nil {
+			return shema.OccurrenceQuery{}, nil, &constants.ValidationError{Field: name, Reason: err.Error()}
+		}
+		q.Filters[field] = value
+	}
+
+	for _, name := range splitList(r.Sort) {
+		desc := strings.HasPrefix(name, "-")
+		field, ok := shema.TsvField(strings.TrimPrefix(name, "-"))
+		if !ok {
+			return shema.OccurrenceQuery{}, nil, &constants.ValidationError{Field: "sort", Reason: "has unknown field " + name}
+		}
+		q.Sort = append(q.Sort, shema.Sort{Field: field, Desc: desc})
+	}
+
+	var fields []string
+	for _, name := range splitList(r.Fields) {
+		field, ok := shema.TsvField(name)
+		if !ok {
+			return shema.OccurrenceQuery{}, nil, &constants.ValidationError{Field: "fields", Reason: "has unknown field " + name}
+		}
+		fields = append(fields, field)
+	}
+	return q, fields, nil
+}
+
+// filterValue check value of typed column and normalize it as rows are, e.g. invert_bit=1 is true
+func filterValue(field, value string) (string, error) {
+	switch shema.TsvKind(field) {
+	case shema.KindInt:
+		n, err := strconv.ParseInt(value, 10, 32)
+		if err != nil {
+			return "", errors.New("is not an integer")
+		}
+		return strconv.FormatInt(n, 10), nil
+	case shema.KindBool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return "", errors.New("is not a boolean")
+		}
+		return strconv.FormatBool(b), nil
+	}
+	return value, nil
+}
+
+// project keep only selected fields of row, all fields if none selected
+func project(t shema.Tsv, fields []string) map[string]interface{} {
+	v := reflect.ValueOf(t)
+	result := make(map[string]interface{})
+	if len(fields) == 0 {
+		for i := 0; i < v.NumField(); i++ {
+			result[v.Type().Field(i).Name] = v.Field(i).Interface()
+		}
+		return result
+	}
+	for _, f := range fields {
+		result[f] = v.FieldByName(f).Interface()
+	}
+	return result
+}
+
+func splitList(s string) []string {
+	var result []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+	"goTSVParser/internal/constants"
+	"goTSVParser/internal/domains/mocks"
+	"goTSVParser/internal/shema"
+	"reflect"
+	"testing"
+)
+
+func TestService_Search(t *testing.T) {
+	levelMin, levelMax := 50, 100
+	row := shema.Tsv{
+		ID:           5,
+		Number:       "5",
+		InventoryID:  "G-044325",
+		UnitGUID:     "01749246-9617-585e-9e19-157ccad61ee2",
+		MessageID:    "cold78_Defrost_status",
+		MessageText:  "Разморозка",
+		MessageClass: "waiting",
+		Level:        "100",
+		Area:         "LOCAL",
+		Address:      "cold78_status.Defrost_status",
+	}
+	tests := []struct {
+		name        string
+		args        shema.SearchRequest
+		storageMock storageMock[shema.SearchRequest]
+		wantPage    shema.Page[map[string]interface{}]
+		wantErr     error
+	}{
+		{
+			name: "OK1",
+			args: shema.SearchRequest{
+				Filters:         map[string]string{"inventory_id": "G-044325", "class": "waiting"},
+				LevelMin:        &levelMin,
+				LevelMax:        &levelMax,
+				MessageIDPrefix: "cold78_",
+				Query:           "разморозка",
+				Sort:            "-level, n",
+				Fields:          "unit_guid,MessageText",
+				Limit:           10,
+				Page:            2,
+			},
+			storageMock: func(c *mocks.Storage, r shema.SearchRequest) {
+				c.Mock.On("GetOccurrences", mock.Anything, shema.OccurrenceQuery{
+					Filters:         map[string]string{"InventoryID": "G-044325", "MessageClass": "waiting"},
+					LevelMin:        &levelMin,
+					LevelMax:        &levelMax,
+					MessageIDPrefix: "cold78_",
+					Text:            "разморозка",
+					Sort:            []shema.Sort{{Field: "Level", Desc: true}, {Field: "Number"}},
+					Limit:           10,
+					Offset:          10,
+				}).Return([]shema.Tsv{row}, 11, nil).Times(1)
+			},
+			wantPage: shema.Page[map[string]interface{}]{
+				Items:    []map[string]interface{}{{"UnitGUID": row.UnitGUID, "MessageText": row.MessageText}},
+				Total:    11,
+				Page:     2,
+				PageSize: 10,
+			},
+		},
+		{
+			name: "OK2",
+			args: shema.SearchRequest{},
+			storageMock: func(c *mocks.Storage, r shema.SearchRequest) {
+				c.Mock.On("GetOccurrences", mock.Anything, shema.OccurrenceQuery{Filters: map[string]string{}, Limit: defaultPageSize}).
+					Return(nil, 0, nil).Times(1)
+			},
+			wantPage: shema.Page[map[string]interface{}]{
+				Items:    []map[string]interface{}{},
+				Page:     1,
+				PageSize: defaultPageSize,
+			},
+		},
+		{
+			name: "OK3",
+			args: shema.SearchRequest{Filters: map[string]string{"level": "0100", "invert_bit": "1"}},
+			storageMock: func(c *mocks.Storage, r shema.SearchRequest) {
+				c.Mock.On("GetOccurrences", mock.Anything, shema.OccurrenceQuery{
+					Filters: map[string]string{"Level": "100", "InvertBit": "true"},
+					Limit:   defaultPageSize,
+				}).Return(nil, 0, nil).Times(1)
+			},
+			wantPage: shema.Page[map[string]interface{}]{
+				Items:    []map[string]interface{}{},
+				Page:     1,
+				PageSize: defaultPageSize,
+			},
+		},
+		{
+			name:        "BAD1",
+			args:        shema.SearchRequest{Filters: map[string]string{"colour": "red"}},
+			storageMock: func(c *mocks.Storage, r shema.SearchRequest) {},
+			wantErr:     constants.ErrInvalidRequest,
+		},
+		{
+			name:        "BAD2",
+			args:        shema.SearchRequest{Sort: "-colour"},
+			storageMock: func(c *mocks.Storage, r shema.SearchRequest) {},
+			wantErr:     constants.ErrInvalidRequest,
+		},
+		{
+			name:        "BAD3",
+			args:        shema.SearchRequest{LevelMin: &levelMax, LevelMax: &levelMin},
+			storageMock: func(c *mocks.Storage, r shema.SearchRequest) {},
+			wantErr:     constants.ErrInvalidRequest,
+		},
+		{
+			name:        "BAD4",
+			args:        shema.SearchRequest{Fields: "unit_guid,colour"},
+			storageMock: func(c *mocks.Storage, r shema.SearchRequest) {},
+			wantErr:     constants.ErrInvalidRequest,
+		},
+		{
+			name:        "BAD5",
+			args:        shema.SearchRequest{Filters: map[string]string{"level": "high"}},
+			storageMock: func(c *mocks.Storage, r shema.SearchRequest) {},
+			wantErr:     constants.ErrInvalidRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := mocks.NewStorage(t)
+			tt.storageMock(storage, tt.args)
+			logger, _ := zap.NewProduction()
+			service := Service{
+				storage: storage,
+				logger:  logger,
+			}
+
+			page, err := service.Search(context.Background(), tt.args)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(page, tt.wantPage) {
+				t.Errorf("got %v, want %v", page, tt.wantPage)
+			}
+		})
+	}
+}
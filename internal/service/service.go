@@ -1,14 +1,38 @@
 package service
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"goTSVParser/config"
 	"goTSVParser/internal/constants"
 	"goTSVParser/internal/domains"
+	"goTSVParser/internal/logging"
+	"goTSVParser/internal/metrics"
 	"goTSVParser/internal/shema"
+	"goTSVParser/internal/tracing"
 	"goTSVParser/internal/workers"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultPageSize = 20
+	defaultMaxPage  = 100
+	// reports of bigger units are refused, they are rendered in memory
+	defaultMaxReportRows = 100000
+	// rows of file are inserted into db by so many at once
+	saveBatchSize = 200
 )
 
 type Service struct {
@@ -16,146 +40,431 @@ type Service struct {
 	watcher *workers.Watcher
 	parser  *workers.Parser
 	writer  *workers.Writer
-	config  config.Config
 	logger  *zap.Logger
+
+	configMu sync.RWMutex
+	config   config.Config
+
+	mu          sync.Mutex
+	lastErr     error
+	lastErrTime time.Time
 }
 
-func NewService(storage domains.Storage, watcher *workers.Watcher, parser *workers.Parser, writer *workers.Writer, config config.Config) *Service {
-	logger, err := zap.NewProduction()
-	if err != nil {
-		return nil
-	}
+func NewService(storage domains.Storage, watcher *workers.Watcher, parser *workers.Parser, writer *workers.Writer, config config.Config, logger *zap.Logger) *Service {
 	return &Service{storage: storage, watcher: watcher, config: config, logger: logger, parser: parser, writer: writer}
 }
 
-// Worker main worker for scan & parse & generate files
+// Reload apply config changed without restart to service, watcher and writer
+func (s *Service) Reload(cfg config.Config) {
+	s.configMu.Lock()
+	s.config = cfg
+	s.configMu.Unlock()
+	s.watcher.Reload(cfg)
+	s.writer.Reload(cfg)
+}
+
+func (s *Service) cfg() config.Config {
+	s.configMu.RLock()
+	defer s.configMu.RUnlock()
+	return s.config
+}
+
+// log return logger of request from ctx, it has request id
+func (s *Service) log(ctx context.Context) *zap.Logger {
+	return logging.From(ctx, s.logger)
+}
+
+// Worker main worker for scan & parse & generate files, when ctx is done current file is finished before return
 func (s *Service) Worker(ctx context.Context) error {
+	err := s.work(ctx)
+	if err != nil && !errors.Is(err, context.Canceled) {
+		s.workerErr(err)
+	}
+	return err
+}
+
+func (s *Service) work(ctx context.Context) error {
 	const op = "service.Worker"
 
 	checkedFiles, err := s.storage.GetCheckedFiles()
 	if err != nil {
-		s.logger.Info(fmt.Sprintf("%s : failed to get checked files: %v", op, err))
+		s.logger.Error("failed to get checked files", zap.String("op", op), zap.Error(err))
 		return err
 	}
 	s.watcher.InitCheckedFiles(checkedFiles)
 
-	out := make(chan string)
+	// occurrence table is created by migrations
+	schemas := s.parser.Schemas()
+	for _, schema := range schemas[:len(schemas)-1] {
+		if err := s.storage.CreateTable(ctx, schema.Schema); err != nil {
+			s.logger.Error("failed to create table of schema", zap.String("op", op), zap.String("schema", schema.Name), zap.Error(err))
+			return err
+		}
+	}
+
+	out := make(chan workers.Found)
 	go s.watcher.Scan(ctx, out)
 
-loop:
 	for {
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
-		case file, ok := <-out:
+		case found, ok := <-out:
 			if !ok {
 				return nil
 			}
 
-			tsvChan, guidChan, errChan := s.parser.ParseFileAsync(file)
-			var tsvArray []shema.Tsv
-			var guidArray []string
-
-			for {
-				select {
-				case <-ctx.Done():
-					return ctx.Err()
-				case tsv, ok := <-tsvChan:
-					if !ok {
-						tsvChan = nil
-					} else {
-						tsvArray = append(tsvArray, tsv)
-
-						err = s.storage.Save(tsv)
-						if err != nil {
-							s.logger.Info(fmt.Sprintf("%s : failed to save data in db: %v", op, err))
-							return err
-						}
-					}
-				case guid, ok := <-guidChan:
-					if !ok {
-						guidChan = nil
-					} else {
-						guidArray = append(guidArray, guid)
-					}
-				case err, ok := <-errChan:
-					if !ok {
-						errChan = nil
-					} else if err != nil {
-						s.logger.Info(fmt.Sprintf("%s : failed to parse file: %v", op, err))
-
-						f := shema.Files{
-							File: file,
-							Err:  err.Error(),
-						}
-
-						err = s.storage.SaveFilesWithErr(f)
-						if err != nil {
-							s.logger.Info(fmt.Sprintf("%s : failed to save file info in db: %v", op, err))
-							return err
-						}
-						continue loop
-					}
-				}
-
-				if tsvChan == nil && guidChan == nil && errChan == nil {
-					break
-				}
+			// file is processed to the end even if ctx is done meanwhile
+			fileCtx := trace.ContextWithSpan(context.WithoutCancel(ctx), found.Span)
+			err := s.processFile(fileCtx, found.Path)
+			if err != nil {
+				tracing.Fail(found.Span, err)
 			}
-
-			err = s.storage.SaveFiles(file)
+			found.Span.End()
 			if err != nil {
-				s.logger.Info(fmt.Sprintf("%s : failed to save file info in db: %v", op, err))
 				return err
 			}
+		}
+	}
+}
 
-			if s.config.SvgGen {
-				err := s.writer.WriteSVG(tsvArray, guidArray, file)
-				if err != nil {
-					s.logger.Info(fmt.Sprintf("%s : failed to write svg: %v", op, err))
-					return err
-				}
-			} else {
-				err = s.writer.WritePDF(tsvArray, guidArray, file)
-				if err != nil {
-					s.logger.Info(fmt.Sprintf("%s : failed to write pdf: %v", op, err))
-					return err
+// processFile save rows of file into db in batches and render reports, parse error only fails the job
+func (s *Service) processFile(ctx context.Context, file string) error {
+	start := time.Now()
+	log := s.logger.With(logging.File(file))
+
+	id, err := newJobID()
+	if err != nil {
+		return err
+	}
+	job := shema.Job{File: file}
+	job.ID, err = s.storage.StartJob(ctx, id, file)
+	if err != nil {
+		log.Error("failed to start job", logging.Stage(metrics.StageSave), zap.Error(err))
+		return err
+	}
+	trace.SpanFromContext(ctx).SetAttributes(attribute.String("job_id", job.ID))
+	log = log.With(zap.String("job_id", job.ID))
+
+	schema := s.parser.SchemaOf(file)
+	log = log.With(zap.String("schema", schema.Name))
+	// parser is cancelled when rows can't be saved, channels are read until it stops
+	parseCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	rowChan, keyChan, errChan, stats := s.parser.ParseFileAsync(parseCtx, file, schema)
+	var rows, batch []shema.Record
+	var keys []string
+	var parseErr, saveErr error
+
+	for rowChan != nil || keyChan != nil || errChan != nil {
+		select {
+		case row, ok := <-rowChan:
+			if !ok {
+				rowChan = nil
+				continue
+			}
+			if saveErr != nil {
+				continue
+			}
+			row.File = file
+			rows = append(rows, row)
+			batch = append(batch, row)
+			if len(batch) == saveBatchSize {
+				if saveErr = s.saveRows(ctx, schema, batch); saveErr != nil {
+					cancel()
 				}
+				batch = batch[:0]
+			}
+		case key, ok := <-keyChan:
+			if !ok {
+				keyChan = nil
+				continue
 			}
+			keys = append(keys, key)
+		case err, ok := <-errChan:
+			if !ok {
+				errChan = nil
+				continue
+			}
+			parseErr = err
 		}
 	}
-	return nil
+	// rows read before parse error are kept as before
+	if saveErr == nil {
+		saveErr = s.saveRows(ctx, schema, batch)
+	}
+	job.Rows, job.UnitGUIDs = len(rows), keys
+	if saveErr != nil {
+		if err = s.finishJob(ctx, job, saveErr); err != nil {
+			log.Error("failed to save job in db", logging.Stage(metrics.StageSave), zap.Error(err))
+		}
+		return saveErr
+	}
+	if len(stats.Dropped) > 0 {
+		job.Dropped = stats.Dropped
+		log = log.With(zap.Any("dropped", stats.Dropped))
+	}
+	if stats.Invalid > 0 {
+		job.Invalid, job.RowErrors = stats.Invalid, stats.Errors
+		log = log.With(zap.Int("invalid", stats.Invalid))
+	}
+
+	if parseErr != nil {
+		log.Warn("failed to parse file", logging.Stage(metrics.StageParse), zap.Int("rows", job.Rows), zap.Error(parseErr))
+		s.workerErr(fmt.Errorf("failed to parse %s: %w", file, parseErr))
+		metrics.FilesFailed.WithLabelValues(metrics.StageParse).Inc()
+		tracing.Fail(trace.SpanFromContext(ctx), parseErr)
+
+		err = s.finishJob(ctx, job, parseErr)
+		if err != nil {
+			log.Error("failed to save job in db", logging.Stage(metrics.StageSave), zap.Error(err))
+			return err
+		}
+
+		err = s.storage.SaveFilesWithErr(ctx, shema.Files{File: file, Err: parseErr.Error()})
+		if err != nil {
+			log.Error("failed to save file info in db", logging.Stage(metrics.StageSave), zap.Error(err))
+			return err
+		}
+		return nil
+	}
+
+	err = s.storage.SaveFiles(ctx, file)
+	if err != nil {
+		log.Error("failed to save file info in db", logging.Stage(metrics.StageSave), zap.Error(err))
+		metrics.FilesFailed.WithLabelValues(metrics.StageSave).Inc()
+		return err
+	}
+
+	job.Status = shema.JobStored
+	err = s.storage.UpdateJob(ctx, job)
+	if err != nil {
+		log.Error("failed to save job in db", logging.Stage(metrics.StageSave), zap.Error(err))
+		return err
+	}
+
+	if s.cfg().SvgGen {
+		err = s.writer.WriteSVG(ctx, schema.Names(), rows, keys, file)
+		if err != nil {
+			log.Error("failed to write svg", logging.Stage(metrics.StageRender), zap.Error(err))
+		}
+	} else {
+		err = s.writer.WritePDF(ctx, schema.Names(), rows, keys, file)
+		if err != nil {
+			log.Error("failed to write pdf", logging.Stage(metrics.StageRender), zap.Error(err))
+		}
+	}
+	if err != nil {
+		s.workerErr(fmt.Errorf("failed to render %s: %w", file, err))
+		metrics.FilesFailed.WithLabelValues(metrics.StageRender).Inc()
+	}
+	if jobErr := s.finishJob(ctx, job, err); jobErr != nil {
+		log.Error("failed to save job in db", logging.Stage(metrics.StageSave), zap.Error(jobErr))
+		return jobErr
+	}
+	if err == nil {
+		log.Info("file processed", zap.Int("rows", job.Rows), zap.Int("units", len(keys)), logging.Duration(time.Since(start)))
+	}
+	return err
 }
 
-// GetAll get data from db
-func (s *Service) GetAll(ctx context.Context, r shema.Request) ([][]shema.Tsv, error) {
+// saveRows save batch of rows of file into table of schema
+func (s *Service) saveRows(ctx context.Context, schema *workers.Schema, rows []shema.Record) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	err := s.storage.Save(ctx, schema.Schema, rows)
+	if err != nil {
+		s.logger.Error("failed to save data in db", logging.File(rows[0].File), logging.Stage(metrics.StageSave),
+			zap.Int("rows", len(rows)), zap.Error(err))
+		metrics.FilesFailed.WithLabelValues(metrics.StageSave).Inc()
+	}
+	return err
+}
+
+// finishJob save job as rendered, or as failed if err is not nil
+func (s *Service) finishJob(ctx context.Context, job shema.Job, err error) error {
+	job.Status = shema.JobRendered
+	if err != nil {
+		job.Status = shema.JobFailed
+		job.Error = err.Error()
+	}
+	return s.storage.UpdateJob(ctx, job)
+}
+
+// GetAll get page of unit data from db
+func (s *Service) GetAll(ctx context.Context, r shema.Request) (shema.Page[shema.Tsv], error) {
 	const op = "service.GetAll"
 
-	tsvFromDB, err := s.storage.GetAllGuids(ctx, r.UnitGUID)
+	q, err := s.occurrenceQuery(r)
+	if err != nil {
+		return shema.Page[shema.Tsv]{}, err
+	}
+
+	// one extra row tells if there is next page
+	q.Limit++
+	tsvFromDB, total, err := s.storage.GetOccurrences(ctx, q)
+	q.Limit--
 	if err != nil {
-		s.logger.Info(fmt.Sprintf("%s : %v", op, err))
-		return nil, constants.ErrNotFound
+		s.log(ctx).Info("request failed", zap.String("op", op), zap.Error(err))
+		return shema.Page[shema.Tsv]{}, err
+	}
+	if total == 0 {
+		return shema.Page[shema.Tsv]{}, constants.ErrNotFound
 	}
-	var resultArray [][]shema.Tsv
 
-	arrayWithPage := SubArray(r.Page, tsvFromDB)
-	for i := 0; i < len(arrayWithPage); i += r.Limit {
-		end := i + r.Limit
+	page := shema.Page[shema.Tsv]{
+		Items:    tsvFromDB,
+		Total:    total,
+		PageSize: q.Limit,
+	}
+	if r.Cursor == "" {
+		page.Page = q.Offset/q.Limit + 1
+	}
+	if page.Items == nil {
+		page.Items = []shema.Tsv{}
+	}
+	if len(tsvFromDB) > q.Limit {
+		page.Items = tsvFromDB[:q.Limit]
+		page.NextCursor = encodeCursor(page.Items[q.Limit-1].ID)
+	}
+	return page, nil
+}
 
-		if end > len(arrayWithPage) {
-			end = len(arrayWithPage)
+func (s *Service) occurrenceQuery(r shema.Request) (shema.OccurrenceQuery, error) {
+	if r.UnitGUID == "" {
+		return shema.OccurrenceQuery{}, &constants.ValidationError{Field: "unit_guid", Reason: "is required"}
+	}
+	cursorPage := r.Page
+	if err := s.pageBounds(&r.Page, &r.Limit); err != nil {
+		return shema.OccurrenceQuery{}, err
+	}
+
+	q := shema.OccurrenceQuery{UnitGUID: r.UnitGUID, Limit: r.Limit}
+	if r.Cursor != "" {
+		if cursorPage > 1 {
+			return shema.OccurrenceQuery{}, &constants.ValidationError{Field: "cursor", Reason: "can't be used with page"}
 		}
+		afterID, err := decodeCursor(r.Cursor)
+		if err != nil {
+			return shema.OccurrenceQuery{}, err
+		}
+		q.AfterID = afterID
+		return q, nil
+	}
 
-		resultArray = append(resultArray, arrayWithPage[i:end])
+	q.Offset = (r.Page - 1) * r.Limit
+	return q, nil
+}
+
+// pageBounds set default page and limit and validate them, max limit is configurable
+func (s *Service) pageBounds(page, limit *int) error {
+	maxPageSize := s.cfg().Limits.MaxPageSize
+	if maxPageSize <= 0 {
+		maxPageSize = defaultMaxPage
+	}
+	if *page == 0 {
+		*page = 1
+	}
+	if *limit == 0 {
+		*limit = min(defaultPageSize, maxPageSize)
 	}
+	if *page < 0 {
+		return &constants.ValidationError{Field: "page", Reason: "must be positive"}
+	}
+	if *limit < 0 || *limit > maxPageSize {
+		return &constants.ValidationError{Field: "limit", Reason: fmt.Sprintf("must be in 1..%d", maxPageSize)}
+	}
+	// offset of page must fit in int
+	if *page-1 > math.MaxInt / *limit {
+		return &constants.ValidationError{Field: "page", Reason: "is too big"}
+	}
+	return nil
+}
 
-	return resultArray, nil
+func encodeCursor(id int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(id, 10)))
 }
 
-func SubArray(startIndex int, data []shema.Tsv) []shema.Tsv {
-	if startIndex < 0 || startIndex >= len(data) {
-		return nil
+func decodeCursor(cursor string) (int64, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, &constants.ValidationError{Field: "cursor", Reason: "is malformed"}
+	}
+	id, err := strconv.ParseInt(string(b), 10, 64)
+	if err != nil || id < 0 {
+		return 0, &constants.ValidationError{Field: "cursor", Reason: "is malformed"}
+	}
+	return id, nil
+}
+
+// GetReport render report of key of schema from db, it is not rendered when ifNoneMatch has its etag
+func (s *Service) GetReport(ctx context.Context, schemaName string, key string, format string, ifNoneMatch string) (shema.Report, error) {
+	const op = "service.GetReport"
+
+	contentType, err := workers.ContentType(format)
+	if err != nil {
+		return shema.Report{}, err
+	}
+	schema, err := s.schemaOf(schemaName)
+	if err != nil {
+		return shema.Report{}, err
+	}
+
+	version, err := s.storage.GetReportVersion(ctx, schema.Schema, key)
+	if err != nil {
+		s.log(ctx).Info("request failed", zap.String("op", op), zap.Error(err))
+		return shema.Report{}, err
+	}
+	etag := reportETag(format, version)
+	if etagMatch(ifNoneMatch, etag) {
+		return shema.Report{ETag: etag, NotModified: true}, nil
+	}
+
+	maxRows := s.cfg().Limits.MaxReportRows
+	if maxRows <= 0 {
+		maxRows = defaultMaxReportRows
+	}
+	if version.Rows > maxRows {
+		return shema.Report{}, fmt.Errorf("%w: key has %d rows, reports are limited to %d", constants.ErrInvalidRequest,
+			version.Rows, maxRows)
 	}
 
-	return data[startIndex:]
+	records, err := s.storage.GetRecords(ctx, schema.Schema, key, version.LastID)
+	if err != nil {
+		s.log(ctx).Info("request failed", zap.String("op", op), zap.Error(err))
+		return shema.Report{}, err
+	}
+
+	var buf bytes.Buffer
+	err = s.writer.Render(ctx, &buf, format, schema.Names(), records, key)
+	if err != nil {
+		s.log(ctx).Error("failed to render report", zap.String("op", op), zap.String("schema", schema.Name),
+			zap.String("key", key), zap.String("format", format), logging.Stage(metrics.StageRender), zap.Error(err))
+		return shema.Report{}, err
+	}
+
+	return shema.Report{
+		Name:        key + "." + format,
+		ContentType: contentType,
+		ETag:        etag,
+		Data:        buf.Bytes(),
+	}, nil
+}
+
+// reportETag hash version of rows, so etag is stable while data in db is the same
+func reportETag(format string, v shema.ReportVersion) string {
+	h := sha256.Sum256([]byte(fmt.Sprintf("%s:%d:%d", format, v.Rows, v.LastID)))
+	return `"` + hex.EncodeToString(h[:16]) + `"`
+}
+
+// etagMatch tell if If-None-Match header has etag, it may be a list or *
+func etagMatch(header, etag string) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == etag || tag == "*" {
+			return true
+		}
+	}
+	return false
 }
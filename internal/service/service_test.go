@@ -5,21 +5,54 @@ import (
 	"errors"
 	"github.com/stretchr/testify/mock"
 	"go.uber.org/zap"
+	"goTSVParser/config"
 	"goTSVParser/internal/constants"
 	"goTSVParser/internal/domains/mocks"
 	"goTSVParser/internal/shema"
+	"goTSVParser/internal/workers"
+	"math"
+	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 )
 
 type storageMock[A any] func(c *mocks.Storage, args A)
 
 func TestService_GetAll(t *testing.T) {
+	rows := []shema.Tsv{
+		{
+			ID:           5,
+			Number:       "5",
+			InventoryID:  "G-044325",
+			UnitGUID:     "01749246-9617-585e-9e19-157ccad61ee2",
+			MessageID:    "cold78_Defrost_status",
+			MessageText:  "Разморозка",
+			MessageClass: "waiting",
+			Level:        "100",
+			Area:         "LOCAL",
+			Address:      "cold78_status.Defrost_status",
+		},
+		{
+			ID:           6,
+			Number:       "6",
+			InventoryID:  "G-044325",
+			UnitGUID:     "01749246-9617-585e-9e19-157ccad61ee2",
+			MessageID:    "cold78_VentSK_status",
+			MessageText:  "Вентилятор",
+			MessageClass: "working",
+			Level:        "100",
+			Area:         "LOCAL",
+			Address:      "cold78_status.VentSK_status",
+		},
+	}
 	tests := []struct {
 		name        string
 		args        shema.Request
+		maxPageSize int
 		storageMock storageMock[shema.Request]
-		wantArr     [][]shema.Tsv
+		wantPage    shema.Page[shema.Tsv]
 		wantErr     error
 	}{
 		{
@@ -31,43 +64,16 @@ func TestService_GetAll(t *testing.T) {
 			},
 
 			storageMock: func(c *mocks.Storage, r shema.Request) {
-				c.Mock.On("GetAllGuids", mock.Anything, r.UnitGUID).Return([]shema.Tsv{
-					{
-						Number:       "5",
-						InventoryID:  "G-044325",
-						UnitGUID:     "01749246-9617-585e-9e19-157ccad61ee2",
-						MessageID:    "cold78_Defrost_status",
-						MessageText:  "Разморозка",
-						MessageClass: "waiting",
-						Level:        "100",
-						Area:         "LOCAL",
-						Address:      "cold78_status.Defrost_status",
-					},
-					{
-						Number:       "6",
-						InventoryID:  "G-044325",
-						UnitGUID:     "01749246-9617-585e-9e19-157ccad61ee2",
-						MessageID:    "cold78_VentSK_status",
-						MessageText:  "Вентилятор",
-						MessageClass: "working",
-						Level:        "100",
-						Area:         "LOCAL",
-						Address:      "cold78_status.VentSK_status",
-					},
-				}, nil).Times(1)
-			},
-			wantArr: [][]shema.Tsv{{
-				{
-					Number:       "6",
-					InventoryID:  "G-044325",
-					UnitGUID:     "01749246-9617-585e-9e19-157ccad61ee2",
-					MessageID:    "cold78_VentSK_status",
-					MessageText:  "Вентилятор",
-					MessageClass: "working",
-					Level:        "100",
-					Area:         "LOCAL",
-					Address:      "cold78_status.VentSK_status",
-				}}},
+				c.Mock.On("GetOccurrences", mock.Anything, shema.OccurrenceQuery{UnitGUID: r.UnitGUID, Limit: 2}).
+					Return(rows, 2, nil).Times(1)
+			},
+			wantPage: shema.Page[shema.Tsv]{
+				Items:      rows[:1],
+				Total:      2,
+				Page:       1,
+				PageSize:   1,
+				NextCursor: encodeCursor(5),
+			},
 			wantErr: nil,
 		},
 		{
@@ -79,46 +85,33 @@ func TestService_GetAll(t *testing.T) {
 			},
 
 			storageMock: func(c *mocks.Storage, r shema.Request) {
-				c.Mock.On("GetAllGuids", mock.Anything, r.UnitGUID).Return([]shema.Tsv{
-					{
-						Number:       "5",
-						InventoryID:  "G-044325",
-						UnitGUID:     "01749246-9617-585e-9e19-157ccad61ee2",
-						MessageID:    "cold78_Defrost_status",
-						MessageText:  "Разморозка",
-						MessageClass: "waiting",
-						Level:        "100",
-						Area:         "LOCAL",
-						Address:      "cold78_status.Defrost_status",
-					},
-					{
-						Number:       "6",
-						InventoryID:  "G-044325",
-						UnitGUID:     "01749246-9617-585e-9e19-157ccad61ee2",
-						MessageID:    "cold78_VentSK_status",
-						MessageText:  "Вентилятор",
-						MessageClass: "working",
-						Level:        "100",
-						Area:         "LOCAL",
-						Address:      "cold78_status.VentSK_status",
-					},
-					{
-						Number: "7",
-					},
-					{
-						Number: "8",
-					},
-				}, nil).Times(1)
-			},
-			wantArr: [][]shema.Tsv{{
-				{
-					Number: "7",
-				},
-			}, {
-				{
-					Number: "8",
-				},
-			}},
+				c.Mock.On("GetOccurrences", mock.Anything, shema.OccurrenceQuery{UnitGUID: r.UnitGUID, Limit: 2, Offset: 1}).
+					Return(rows[1:], 2, nil).Times(1)
+			},
+			wantPage: shema.Page[shema.Tsv]{
+				Items:    rows[1:],
+				Total:    2,
+				Page:     2,
+				PageSize: 1,
+			},
+			wantErr: nil,
+		},
+		{
+			name: "OK3",
+			args: shema.Request{
+				UnitGUID: "01749246-9617-585e-9e19-157ccad61ee2",
+				Cursor:   encodeCursor(5),
+			},
+
+			storageMock: func(c *mocks.Storage, r shema.Request) {
+				c.Mock.On("GetOccurrences", mock.Anything, shema.OccurrenceQuery{UnitGUID: r.UnitGUID, Limit: defaultPageSize + 1, AfterID: 5}).
+					Return(rows[1:], 2, nil).Times(1)
+			},
+			wantPage: shema.Page[shema.Tsv]{
+				Items:    rows[1:],
+				Total:    2,
+				PageSize: defaultPageSize,
+			},
 			wantErr: nil,
 		},
 		{
@@ -130,29 +123,422 @@ func TestService_GetAll(t *testing.T) {
 			},
 
 			storageMock: func(c *mocks.Storage, r shema.Request) {
-				c.Mock.On("GetAllGuids", mock.Anything, r.UnitGUID).Return(nil, errors.New("error getting")).Times(1)
+				c.Mock.On("GetOccurrences", mock.Anything, mock.Anything).Return(nil, 0, nil).Times(1)
 			},
-			wantArr: nil,
 			wantErr: constants.ErrNotFound,
 		},
+		{
+			name: "BAD2",
+			args: shema.Request{
+				UnitGUID: "01749246-9617-585e-9e19-157ccad61ee2",
+				Limit:    defaultMaxPage + 1,
+			},
+			storageMock: func(c *mocks.Storage, r shema.Request) {},
+			wantErr:     constants.ErrInvalidRequest,
+		},
+		{
+			name: "BAD3",
+			args: shema.Request{
+				UnitGUID: "01749246-9617-585e-9e19-157ccad61ee2",
+				Page:     -1,
+			},
+			storageMock: func(c *mocks.Storage, r shema.Request) {},
+			wantErr:     constants.ErrInvalidRequest,
+		},
+		{
+			name: "BAD4",
+			args: shema.Request{
+				UnitGUID: "01749246-9617-585e-9e19-157ccad61ee2",
+				Cursor:   "not a cursor",
+			},
+			storageMock: func(c *mocks.Storage, r shema.Request) {},
+			wantErr:     constants.ErrInvalidRequest,
+		},
+		{
+			name:        "BAD5",
+			args:        shema.Request{Limit: 1},
+			storageMock: func(c *mocks.Storage, r shema.Request) {},
+			wantErr:     constants.ErrInvalidRequest,
+		},
+		{
+			name: "BAD6",
+			args: shema.Request{
+				UnitGUID: "01749246-9617-585e-9e19-157ccad61ee2",
+				Limit:    11,
+			},
+			maxPageSize: 10,
+			storageMock: func(c *mocks.Storage, r shema.Request) {},
+			wantErr:     constants.ErrInvalidRequest,
+		},
+		{
+			name: "BAD7",
+			args: shema.Request{
+				UnitGUID: "01749246-9617-585e-9e19-157ccad61ee2",
+				Limit:    100,
+				Page:     math.MaxInt/100 + 2,
+			},
+			storageMock: func(c *mocks.Storage, r shema.Request) {},
+			wantErr:     constants.ErrInvalidRequest,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			storage := mocks.NewStorage(t)
 			tt.storageMock(storage, tt.args)
-			logger, err := zap.NewProduction()
+			logger, _ := zap.NewProduction()
 
 			service := Service{
 				storage: storage,
 				logger:  logger,
+				config:  config.Config{Limits: config.Limits{MaxPageSize: tt.maxPageSize}},
 			}
 			ctx := context.Background()
-			tsvs, err := service.GetAll(ctx, tt.args)
+			page, err := service.GetAll(ctx, tt.args)
 			if !errors.Is(err, tt.wantErr) {
 				t.Errorf("got %v, want %v", err, tt.wantErr)
 			}
-			if !reflect.DeepEqual(tsvs, tt.wantArr) {
-				t.Errorf("got %v, want %v", tsvs, tt.wantArr)
+			if !reflect.DeepEqual(page, tt.wantPage) {
+				t.Errorf("got %v, want %v", page, tt.wantPage)
+			}
+		})
+	}
+}
+
+func TestService_GetReport(t *testing.T) {
+	guid := "01749246-9617-585e-9e19-157ccad61ee2"
+	version := shema.ReportVersion{Rows: 1, LastID: 5}
+	etag := reportETag(workers.FormatHTML, version)
+	occurrence := mock.MatchedBy(func(s config.Schema) bool { return s.Name == workers.OccurrenceName })
+	tests := []struct {
+		name          string
+		schema        string
+		format        string
+		ifNoneMatch   string
+		maxReportRows int
+		storageMock   storageMock[string]
+		wantReport    bool
+		wantModified  bool
+		wantErr       error
+	}{
+		{
+			name:   "OK1",
+			format: workers.FormatHTML,
+			storageMock: func(c *mocks.Storage, guid string) {
+				c.Mock.On("GetReportVersion", mock.Anything, occurrence, guid).Return(version, nil).Times(1)
+				c.Mock.On("GetRecords", mock.Anything, occurrence, guid, version.LastID).
+					Return([]shema.Record{shema.Tsv{ID: 5, UnitGUID: guid, MessageText: "Разморозка"}.Record()}, nil).Times(1)
+			},
+			wantReport:   true,
+			wantModified: true,
+		},
+		{
+			name:        "OK2",
+			format:      workers.FormatHTML,
+			ifNoneMatch: `"other", ` + etag,
+			storageMock: func(c *mocks.Storage, guid string) {
+				c.Mock.On("GetReportVersion", mock.Anything, occurrence, guid).Return(version, nil).Times(1)
+			},
+			wantReport: true,
+		},
+		{
+			name:        "BAD1",
+			schema:      "alarms",
+			format:      workers.FormatHTML,
+			storageMock: func(c *mocks.Storage, guid string) {},
+			wantErr:     constants.ErrBadRequest,
+		},
+		{
+			name:        "BAD2",
+			format:      "doc",
+			storageMock: func(c *mocks.Storage, guid string) {},
+			wantErr:     constants.ErrUnsupportedFormat,
+		},
+		{
+			name:   "BAD3",
+			format: workers.FormatHTML,
+			storageMock: func(c *mocks.Storage, guid string) {
+				c.Mock.On("GetReportVersion", mock.Anything, occurrence, guid).Return(shema.ReportVersion{}, constants.ErrNotFound).Times(1)
+			},
+			wantErr: constants.ErrNotFound,
+		},
+		{
+			name:          "BAD4",
+			format:        workers.FormatHTML,
+			maxReportRows: 1,
+			storageMock: func(c *mocks.Storage, guid string) {
+				c.Mock.On("GetReportVersion", mock.Anything, occurrence, guid).Return(shema.ReportVersion{Rows: 2, LastID: 6}, nil).Times(1)
+			},
+			wantErr: constants.ErrInvalidRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := mocks.NewStorage(t)
+			tt.storageMock(storage, guid)
+
+			parser, err := workers.NewParser(config.Config{}, zap.NewNop())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.schema == "" {
+				tt.schema = workers.OccurrenceName
+			}
+			service := Service{
+				storage: storage,
+				parser:  parser,
+				writer:  workers.NewWriter(config.Config{}),
+				logger:  zap.NewNop(),
+				config:  config.Config{Limits: config.Limits{MaxReportRows: tt.maxReportRows}},
+			}
+			report, err := service.GetReport(context.Background(), tt.schema, guid, tt.format, tt.ifNoneMatch)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got %v, want %v", err, tt.wantErr)
+			}
+			if !tt.wantReport {
+				return
+			}
+			if report.ETag != etag || report.NotModified == tt.wantModified {
+				t.Errorf("got etag %s and not modified %v", report.ETag, report.NotModified)
+			}
+			if tt.wantModified && !strings.Contains(string(report.Data), "Разморозка") {
+				t.Errorf("report is not rendered: %s", report.Data)
+			}
+		})
+	}
+}
+
+func TestService_GetRecords(t *testing.T) {
+	guid := "01749246-9617-585e-9e19-157ccad61ee2"
+	occurrence := mock.MatchedBy(func(s config.Schema) bool { return s.Name == workers.OccurrenceName })
+	tests := []struct {
+		name        string
+		schema      string
+		request     shema.RecordRequest
+		storageMock storageMock[string]
+		wantItems   int
+		wantErr     error
+	}{
+		{
+			name:    "OK1",
+			request: shema.RecordRequest{Page: 2, Limit: 1},
+			storageMock: func(c *mocks.Storage, guid string) {
+				c.Mock.On("GetRecordsPage", mock.Anything, occurrence, guid, 1, 1).
+					Return([]shema.Record{shema.Tsv{ID: 5, UnitGUID: guid, MessageText: "Разморозка"}.Record()}, 2, nil).Times(1)
+			},
+			wantItems: 1,
+		},
+		{
+			name:        "BAD1",
+			schema:      "alarms",
+			storageMock: func(c *mocks.Storage, guid string) {},
+			wantErr:     constants.ErrBadRequest,
+		},
+		{
+			name:        "BAD2",
+			request:     shema.RecordRequest{Page: -1},
+			storageMock: func(c *mocks.Storage, guid string) {},
+			wantErr:     constants.ErrInvalidRequest,
+		},
+		{
+			name: "BAD3",
+			storageMock: func(c *mocks.Storage, guid string) {
+				c.Mock.On("GetRecordsPage", mock.Anything, occurrence, guid, defaultPageSize, 0).Return(nil, 0, nil).Times(1)
+			},
+			wantErr: constants.ErrNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := mocks.NewStorage(t)
+			tt.storageMock(storage, guid)
+
+			parser, err := workers.NewParser(config.Config{}, zap.NewNop())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.schema == "" {
+				tt.schema = workers.OccurrenceName
+			}
+			service := Service{
+				storage: storage,
+				parser:  parser,
+				logger:  zap.NewNop(),
+			}
+			page, err := service.GetRecords(context.Background(), tt.schema, guid, tt.request)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got %v, want %v", err, tt.wantErr)
+			}
+			if len(page.Items) != tt.wantItems {
+				t.Fatalf("got %d items, want %d", len(page.Items), tt.wantItems)
+			}
+			if tt.wantItems > 0 && (page.Items[0]["id"] != int64(5) || page.Items[0]["unit_guid"] != guid || page.Total != 2) {
+				t.Errorf("got %v", page)
+			}
+		})
+	}
+}
+
+func TestService_ProcessFile(t *testing.T) {
+	row := "1\tmqtt\tG-044325\t01749246-9617-585e-9e19-157ccad61ee2\tcold78_Defrost_status\tРазморозка\t\twaiting\t100\tLOCAL\tcold78_status.Defrost_status\t\t\t\t\n"
+	saveErr := errors.New("connection reset")
+	rowsOf := func(n int) interface{} {
+		return mock.MatchedBy(func(rows []shema.Record) bool { return len(rows) == n })
+	}
+	tableOf := func(table string) interface{} {
+		return mock.MatchedBy(func(s config.Schema) bool { return s.Table == table })
+	}
+	alarms := config.Schema{Name: "alarms", Match: "alarms/*.tsv", Table: "alarms", Key: "device",
+		Columns: []config.Column{{Name: "device"}, {Name: "code", Type: "int"}}}
+	tests := []struct {
+		name        string
+		file        string
+		rows        int
+		extra       string // rows which are not saved
+		storageMock storageMock[string]
+		wantErr     error
+	}{
+		{
+			name: "OK1",
+			file: "a.tsv",
+			rows: saveBatchSize + 1,
+			storageMock: func(c *mocks.Storage, file string) {
+				c.Mock.On("StartJob", mock.Anything, mock.Anything, file).Return("job1", nil).Times(1)
+				c.Mock.On("Save", mock.Anything, tableOf("occurrence"), rowsOf(saveBatchSize)).Return(nil).Times(1)
+				c.Mock.On("Save", mock.Anything, tableOf("occurrence"), rowsOf(1)).Return(nil).Times(1)
+				c.Mock.On("SaveFiles", mock.Anything, file).Return(nil).Times(1)
+				c.Mock.On("UpdateJob", mock.Anything, mock.MatchedBy(func(j shema.Job) bool {
+					return j.Status == shema.JobStored && j.Rows == saveBatchSize+1
+				})).Return(nil).Times(1)
+				c.Mock.On("UpdateJob", mock.Anything, mock.MatchedBy(func(j shema.Job) bool {
+					return j.Status == shema.JobRendered
+				})).Return(nil).Times(1)
+			},
+		},
+		{
+			name: "OK2",
+			file: "a.txt",
+			storageMock: func(c *mocks.Storage, file string) {
+				c.Mock.On("StartJob", mock.Anything, mock.Anything, file).Return("job1", nil).Times(1)
+				c.Mock.On("UpdateJob", mock.Anything, mock.MatchedBy(func(j shema.Job) bool {
+					return j.Status == shema.JobFailed
+				})).Return(nil).Times(1)
+				c.Mock.On("SaveFilesWithErr", mock.Anything, shema.Files{File: file, Err: constants.ErrNotTSV.Error()}).Return(nil).Times(1)
+			},
+		},
+		{
+			name:  "OK3",
+			file:  "a.tsv",
+			rows:  1,
+			extra: "2\tmqtt\tG-044325\tshort\tcold78_Defrost_status\tРазморозка\t\twaiting\t100\tLOCAL\t\t\t\t\t\n",
+			storageMock: func(c *mocks.Storage, file string) {
+				c.Mock.On("StartJob", mock.Anything, mock.Anything, file).Return("job1", nil).Times(1)
+				c.Mock.On("Save", mock.Anything, tableOf("occurrence"), rowsOf(1)).Return(nil).Times(1)
+				c.Mock.On("SaveFiles", mock.Anything, file).Return(nil).Times(1)
+				c.Mock.On("UpdateJob", mock.Anything, mock.MatchedBy(func(j shema.Job) bool {
+					return j.Status == shema.JobStored && j.Rows == 1 && j.Dropped["unit_guid_too_short"] == 1
+				})).Return(nil).Times(1)
+				c.Mock.On("UpdateJob", mock.Anything, mock.MatchedBy(func(j shema.Job) bool {
+					return j.Status == shema.JobRendered
+				})).Return(nil).Times(1)
+			},
+		},
+		{
+			name:  "OK4",
+			file:  "a.tsv",
+			rows:  1,
+			extra: "2\tmqtt\tG-044325\t01749246-9617-585e-9e19-157ccad61ee2\tcold78_Defrost_status\tРазморозка\t\twaiting\thigh\tLOCAL\t\t\t\t\t\n",
+			storageMock: func(c *mocks.Storage, file string) {
+				c.Mock.On("StartJob", mock.Anything, mock.Anything, file).Return("job1", nil).Times(1)
+				c.Mock.On("Save", mock.Anything, tableOf("occurrence"), rowsOf(1)).Return(nil).Times(1)
+				c.Mock.On("SaveFiles", mock.Anything, file).Return(nil).Times(1)
+				c.Mock.On("UpdateJob", mock.Anything, mock.MatchedBy(func(j shema.Job) bool {
+					return j.Status == shema.JobStored && j.Invalid == 1 &&
+						reflect.DeepEqual(j.RowErrors, []shema.RowError{{Line: 2, Field: "level", Value: "high", Reason: "is not an integer"}})
+				})).Return(nil).Times(1)
+				c.Mock.On("UpdateJob", mock.Anything, mock.MatchedBy(func(j shema.Job) bool {
+					return j.Status == shema.JobRendered
+				})).Return(nil).Times(1)
+			},
+		},
+		{
+			name:  "OK5",
+			file:  "alarms/a.tsv",
+			extra: "dev-1\t7\ndev-2\t\n",
+			storageMock: func(c *mocks.Storage, file string) {
+				c.Mock.On("StartJob", mock.Anything, mock.Anything, file).Return("job1", nil).Times(1)
+				c.Mock.On("Save", mock.Anything, tableOf("alarms"), rowsOf(2)).Return(nil).Times(1)
+				c.Mock.On("SaveFiles", mock.Anything, file).Return(nil).Times(1)
+				c.Mock.On("UpdateJob", mock.Anything, mock.MatchedBy(func(j shema.Job) bool {
+					return j.Status == shema.JobStored && reflect.DeepEqual(j.UnitGUIDs, []string{"dev-1", "dev-2"})
+				})).Return(nil).Times(1)
+				c.Mock.On("UpdateJob", mock.Anything, mock.MatchedBy(func(j shema.Job) bool {
+					return j.Status == shema.JobRendered
+				})).Return(nil).Times(1)
+			},
+		},
+		{
+			name: "BAD1",
+			file: "a.tsv",
+			rows: 2,
+			storageMock: func(c *mocks.Storage, file string) {
+				c.Mock.On("StartJob", mock.Anything, mock.Anything, file).Return("job1", nil).Times(1)
+				c.Mock.On("Save", mock.Anything, tableOf("occurrence"), rowsOf(2)).Return(saveErr).Times(1)
+				c.Mock.On("UpdateJob", mock.Anything, mock.MatchedBy(func(j shema.Job) bool {
+					return j.Status == shema.JobFailed && j.Error == saveErr.Error()
+				})).Return(nil).Times(1)
+			},
+			wantErr: saveErr,
+		},
+		{
+			name: "BAD2",
+			file: "a.tsv",
+			rows: 2*saveBatchSize + 1,
+			storageMock: func(c *mocks.Storage, file string) {
+				c.Mock.On("StartJob", mock.Anything, mock.Anything, file).Return("job1", nil).Times(1)
+				c.Mock.On("Save", mock.Anything, tableOf("occurrence"), rowsOf(saveBatchSize)).Return(saveErr).Times(1)
+				c.Mock.On("UpdateJob", mock.Anything, mock.MatchedBy(func(j shema.Job) bool {
+					return j.Status == shema.JobFailed && j.Error == saveErr.Error()
+				})).Return(nil).Times(1)
+			},
+			wantErr: saveErr,
+		},
+	}
+	// svg template is read from repo root
+	wd, _ := os.Getwd()
+	if err := os.Chdir("../.."); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.Config{DirectoryFrom: t.TempDir(), DirectoryTo: t.TempDir(), SvgGen: true,
+				Parser: config.Parser{Filters: config.DefaultFilters, Schemas: []config.Schema{alarms}}}
+			file := filepath.Join(cfg.DirectoryFrom, tt.file)
+			if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(file, []byte(strings.Repeat(row, tt.rows)+tt.extra), 0644); err != nil {
+				t.Fatal(err)
+			}
+			storage := mocks.NewStorage(t)
+			tt.storageMock(storage, file)
+			logger, _ := zap.NewProduction()
+
+			parser, err := workers.NewParser(cfg, zap.NewNop())
+			if err != nil {
+				t.Fatal(err)
+			}
+			service := Service{
+				storage: storage,
+				parser:  parser,
+				writer:  workers.NewWriter(cfg),
+				config:  cfg,
+				logger:  logger,
+			}
+			err = service.processFile(context.Background(), file)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("got %v, want %v", err, tt.wantErr)
 			}
 		})
 	}
@@ -0,0 +1,40 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"go.uber.org/zap"
+	"goTSVParser/internal/constants"
+	"goTSVParser/internal/shema"
+)
+
+const (
+	defaultStatsGroups = 100
+	maxStatsGroups     = 1000
+)
+
+// GetStats count messages of unit or inventory by class, level, area, file and unit
+func (s *Service) GetStats(ctx context.Context, f shema.StatsFilter) (shema.Stats, error) {
+	const op = "service.GetStats"
+
+	if f.Limit == 0 {
+		f.Limit = defaultStatsGroups
+	}
+	if f.Limit < 0 || f.Limit > maxStatsGroups {
+		return shema.Stats{}, &constants.ValidationError{Field: "limit", Reason: fmt.Sprintf("must be in 1..%d", maxStatsGroups)}
+	}
+
+	q := shema.OccurrenceQuery{UnitGUID: f.UnitGUID}
+	if f.InventoryID != "" {
+		q.Filters = map[string]string{"InventoryID": f.InventoryID}
+	}
+	stats, err := s.storage.GetStats(ctx, q, f.Limit)
+	if err != nil {
+		s.log(ctx).Info("request failed", zap.String("op", op), zap.Error(err))
+		return shema.Stats{}, err
+	}
+	if stats.Total == 0 && (f.UnitGUID != "" || f.InventoryID != "") {
+		return shema.Stats{}, constants.ErrNotFound
+	}
+	return stats, nil
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+	"goTSVParser/internal/constants"
+	"goTSVParser/internal/domains/mocks"
+	"goTSVParser/internal/shema"
+	"reflect"
+	"testing"
+)
+
+func TestService_GetStats(t *testing.T) {
+	stats := shema.Stats{
+		Total:   3,
+		ByClass: []shema.Count{{Key: "alarm", Count: 2}, {Key: "working", Count: 1}},
+		ByLevel: []shema.Count{{Key: "100", Count: 3}},
+		ByArea:  []shema.Count{{Key: "LOCAL", Count: 3}},
+		ByFile:  []shema.Count{{Key: "from/units.tsv", Count: 3}},
+		ByUnit:  []shema.Count{{Key: "01749246-95f6-57db-b7c3-2ae0e8be671f", Count: 3}},
+	}
+	tests := []struct {
+		name        string
+		args        shema.StatsFilter
+		storageMock storageMock[shema.StatsFilter]
+		wantStats   shema.Stats
+		wantErr     error
+	}{
+		{
+			name: "OK1",
+			args: shema.StatsFilter{InventoryID: "G-044322"},
+			storageMock: func(c *mocks.Storage, f shema.StatsFilter) {
+				c.Mock.On("GetStats", mock.Anything, shema.OccurrenceQuery{Filters: map[string]string{"InventoryID": "G-044322"}}, defaultStatsGroups).
+					Return(stats, nil).Times(1)
+			},
+			wantStats: stats,
+		},
+		{
+			name: "OK2",
+			args: shema.StatsFilter{Limit: 5},
+			storageMock: func(c *mocks.Storage, f shema.StatsFilter) {
+				c.Mock.On("GetStats", mock.Anything, shema.OccurrenceQuery{}, 5).Return(shema.Stats{}, nil).Times(1)
+			},
+			wantStats: shema.Stats{},
+		},
+		{
+			name: "BAD1",
+			args: shema.StatsFilter{UnitGUID: "1yua683"},
+			storageMock: func(c *mocks.Storage, f shema.StatsFilter) {
+				c.Mock.On("GetStats", mock.Anything, shema.OccurrenceQuery{UnitGUID: "1yua683"}, defaultStatsGroups).
+					Return(shema.Stats{}, nil).Times(1)
+			},
+			wantErr: constants.ErrNotFound,
+		},
+		{
+			name:        "BAD2",
+			args:        shema.StatsFilter{Limit: maxStatsGroups + 1},
+			storageMock: func(c *mocks.Storage, f shema.StatsFilter) {},
+			wantErr:     constants.ErrInvalidRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := mocks.NewStorage(t)
+			tt.storageMock(storage, tt.args)
+			logger, _ := zap.NewProduction()
+			service := Service{
+				storage: storage,
+				logger:  logger,
+			}
+
+			stats, err := service.GetStats(context.Background(), tt.args)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(stats, tt.wantStats) {
+				t.Errorf("got %v, want %v", stats, tt.wantStats)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"go.uber.org/zap"
+	"goTSVParser/internal/constants"
+	"goTSVParser/internal/logging"
+	"goTSVParser/internal/metrics"
+	"goTSVParser/internal/shema"
+	"goTSVParser/internal/workers"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const uploadDir = "uploads"
+
+// Upload save file into input directory, watcher picks it up on next scan
+func (s *Service) Upload(ctx context.Context, fileName string, r io.Reader) (shema.Job, error) {
+	const op = "service.Upload"
+
+	fileName = filepath.Base(fileName)
+	format, ok := s.parser.FormatOf(fileName)
+	if !ok || strings.HasPrefix(fileName, ".") {
+		return shema.Job{}, constants.ErrNotTSV
+	}
+
+	id, err := newJobID()
+	if err != nil {
+		return shema.Job{}, err
+	}
+
+	dir := filepath.Join(s.cfg().DirectoryFrom, uploadDir, id)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return shema.Job{}, fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	// hidden name so watcher skips the file until it is complete, extension is kept for decompression
+	partFile := filepath.Join(dir, "."+fileName)
+	schema := s.parser.SchemaOf(filepath.Join(dir, fileName))
+	err = writeUpload(partFile, r)
+	if err == nil {
+		err = validateInput(partFile, format, schema)
+	}
+	if err != nil {
+		s.log(ctx).Info("upload rejected", zap.String("op", op), logging.File(fileName), logging.Stage(metrics.StageUpload), zap.Error(err))
+		metrics.FilesFailed.WithLabelValues(metrics.StageUpload).Inc()
+		os.RemoveAll(dir)
+		return shema.Job{}, err
+	}
+
+	job := shema.Job{ID: id, File: filepath.Join(dir, fileName), Status: shema.JobPending}
+	err = s.storage.CreateJob(ctx, job)
+	if err != nil {
+		s.log(ctx).Info("request failed", zap.String("op", op), zap.Error(err))
+		os.RemoveAll(dir)
+		return shema.Job{}, err
+	}
+
+	if err := os.Rename(partFile, job.File); err != nil {
+		os.RemoveAll(dir)
+		return shema.Job{}, fmt.Errorf("failed to move upload: %w", err)
+	}
+
+	return job, nil
+}
+
+func newJobID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate job id: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func writeUpload(path string, r io.Reader) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer file.Close()
+
+	_, err = io.Copy(file, r)
+	if err != nil {
+		return fmt.Errorf("failed to save upload: %w", err)
+	}
+	return nil
+}
+
+// validateInput check that every row of file has all columns of schema
+func validateInput(path string, format string, schema *workers.Schema) error {
+	reader, err := workers.OpenInput(path, format, schema)
+	if err != nil {
+		return fmt.Errorf("%w: %v", constants.ErrInvalidTSV, err)
+	}
+	defer reader.Close()
+	rows := 0
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %v", constants.ErrInvalidTSV, err)
+		}
+		rows++
+		if len(row) < len(schema.Columns) {
+			return fmt.Errorf("%w: row %d has %d columns, want %d", constants.ErrInvalidTSV, rows, len(row), len(schema.Columns))
+		}
+	}
+	if rows == 0 {
+		return fmt.Errorf("%w: file is empty", constants.ErrInvalidTSV)
+	}
+	return nil
+}
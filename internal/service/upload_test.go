@@ -0,0 +1,143 @@
+package service
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+	"goTSVParser/config"
+	"goTSVParser/internal/constants"
+	"goTSVParser/internal/domains/mocks"
+	"goTSVParser/internal/shema"
+	"goTSVParser/internal/workers"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var errDB = errors.New("db is down")
+
+func gzipOf(t *testing.T, data string) string {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	w.Write([]byte(data))
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestService_Upload(t *testing.T) {
+	row := "5\t\tG-044325\t01749246-9617-585e-9e19-157ccad61ee2\tcold78_Defrost_status\tРазморозка\t\twaiting\t100\tLOCAL\tcold78_status.Defrost_status\t\t\t\t\n"
+	tests := []struct {
+		name        string
+		fileName    string
+		content     string
+		storageMock storageMock[string]
+		wantErr     error
+	}{
+		{
+			name:     "OK1",
+			fileName: "OK1.tsv",
+			content:  row + row,
+			storageMock: func(c *mocks.Storage, fileName string) {
+				c.Mock.On("CreateJob", mock.Anything, mock.MatchedBy(func(job shema.Job) bool {
+					return job.Status == shema.JobPending && filepath.Base(job.File) == fileName
+				})).Return(nil).Times(1)
+			},
+			wantErr: nil,
+		},
+		{
+			name:     "OK2",
+			fileName: "OK2.csv",
+			content:  strings.ReplaceAll(row, "\t", ";"),
+			storageMock: func(c *mocks.Storage, fileName string) {
+				c.Mock.On("CreateJob", mock.Anything, mock.Anything).Return(nil).Times(1)
+			},
+		},
+		{
+			name:     "OK3",
+			fileName: "OK3.tsv.gz",
+			content:  gzipOf(t, row),
+			storageMock: func(c *mocks.Storage, fileName string) {
+				c.Mock.On("CreateJob", mock.Anything, mock.Anything).Return(nil).Times(1)
+			},
+		},
+		{
+			name:     "BAD4",
+			fileName: "BAD4.tsv",
+			content:  row,
+			storageMock: func(c *mocks.Storage, fileName string) {
+				c.Mock.On("CreateJob", mock.Anything, mock.Anything).Return(errDB).Times(1)
+			},
+			wantErr: errDB,
+		},
+		{
+			name:     "BAD1",
+			fileName: "BAD1.txt",
+			content:  row,
+			wantErr:  constants.ErrNotTSV,
+		},
+		{
+			name:     "BAD2",
+			fileName: "BAD2.tsv",
+			content:  "5\tG-044325\n",
+			wantErr:  constants.ErrInvalidTSV,
+		},
+		{
+			name:     "BAD3",
+			fileName: "BAD3.tsv",
+			content:  "",
+			wantErr:  constants.ErrInvalidTSV,
+		},
+		{
+			name:     "BAD5",
+			fileName: "BAD5.tsv.gz",
+			content:  row,
+			wantErr:  constants.ErrInvalidTSV,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dirFrom := t.TempDir()
+			storage := mocks.NewStorage(t)
+			if tt.storageMock != nil {
+				tt.storageMock(storage, tt.fileName)
+			}
+			logger, _ := zap.NewProduction()
+			cfg := config.Config{DirectoryFrom: dirFrom}
+			parser, err := workers.NewParser(cfg, zap.NewNop())
+			if err != nil {
+				t.Fatal(err)
+			}
+			service := Service{
+				storage: storage,
+				parser:  parser,
+				config:  cfg,
+				logger:  logger,
+			}
+
+			job, err := service.Upload(context.Background(), tt.fileName, strings.NewReader(tt.content))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				entries, _ := os.ReadDir(filepath.Join(dirFrom, uploadDir))
+				if len(entries) != 0 {
+					t.Errorf("rejected upload left %d entries", len(entries))
+				}
+				return
+			}
+			if job.Status != shema.JobPending {
+				t.Errorf("got status %s, want %s", job.Status, shema.JobPending)
+			}
+			content, err := os.ReadFile(job.File)
+			if err != nil || string(content) != tt.content {
+				t.Errorf("got content %q, want %q", content, tt.content)
+			}
+		})
+	}
+}
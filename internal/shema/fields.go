@@ -0,0 +1,67 @@
+package shema
+
+import (
+	"reflect"
+	"strings"
+)
+
+// kinds of Tsv fields, all of them are strings in Tsv
+const (
+	KindString = "string" // varchar(255)
+	KindText   = "text"   // text of any length
+	KindInt    = "int"    // integer, empty is null
+	KindBool   = "bool"   // boolean, empty is null
+	KindGUID   = "guid"   // required guid, e.g. 01749246-9617-585e-9e19-157ccad61ee2
+)
+
+var (
+	tsvFields = map[string]string{}
+	tsvKinds  = map[string]string{}
+	// TsvColumns are tsv tags in order of columns of file
+	TsvColumns []string
+	tsvIndex   []int
+)
+
+func init() {
+	t := reflect.TypeOf(Tsv{})
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tsvFields[normalize(f.Name)] = f.Name
+		if tag := f.Tag.Get("tsv"); tag != "" {
+			tsvFields[normalize(tag)] = f.Name
+			TsvColumns = append(TsvColumns, tag)
+			tsvIndex = append(tsvIndex, i)
+		}
+		if kind := f.Tag.Get("type"); kind != "" {
+			tsvKinds[f.Name] = kind
+		}
+	}
+}
+
+// TsvKind return kind of db column of Tsv field, e.g. KindInt for "Level"
+func TsvKind(field string) string {
+	if kind, ok := tsvKinds[field]; ok {
+		return kind
+	}
+	return KindString
+}
+
+func normalize(name string) string {
+	return strings.ToLower(strings.ReplaceAll(name, "_", ""))
+}
+
+// TsvField resolve field of Tsv by name, snake_case name or tsv tag, e.g. "unit_guid" and "UnitGUID" give "UnitGUID"
+func TsvField(name string) (string, bool) {
+	field, ok := tsvFields[normalize(name)]
+	return field, ok
+}
+
+// Record of row with values in order of TsvColumns, key is unit guid
+func (t Tsv) Record() Record {
+	v := reflect.ValueOf(t)
+	r := Record{ID: t.ID, Key: t.UnitGUID, Values: make([]string, len(tsvIndex)), File: t.File}
+	for i, field := range tsvIndex {
+		r.Values[i] = v.Field(field).String()
+	}
+	return r
+}
@@ -1,21 +1,55 @@
 package shema
 
+import "time"
+
+// Tsv is row of file, type tag is type of db column, see TsvKind
 type Tsv struct {
-	Number       string `tsv:"n"`
+	ID           int64
+	Number       string `tsv:"n" type:"int"`
 	MQTT         string `tsv:"mqtt"`
 	InventoryID  string `tsv:"invid"`
-	UnitGUID     string `tsv:"unit_guid"`
+	UnitGUID     string `tsv:"unit_guid" type:"guid"`
 	MessageID    string `tsv:"msg_id"`
-	MessageText  string `tsv:"text"`
+	MessageText  string `tsv:"text" type:"text"`
 	Context      string `tsv:"context"`
 	MessageClass string `tsv:"class"`
-	Level        string `tsv:"level"`
+	Level        string `tsv:"level" type:"int"`
 	Area         string `tsv:"area"`
 	Address      string `tsv:"addr"`
 	Block        string `tsv:"block"`
 	Type         string `tsv:"type"`
-	Bit          string `tsv:"bit"`
-	InvertBit    string `tsv:"invert_bit"`
+	Bit          string `tsv:"bit" type:"int"`
+	InvertBit    string `tsv:"invert_bit" type:"bool"`
+	File         string
+}
+
+// Record is row of file of any schema, values are in order of schema columns and key groups rows into reports
+type Record struct {
+	ID     int64 // id in db, zero before saving
+	Key    string
+	Values []string
+	File   string
+}
+
+// RecordRequest is page of rows of key of schema
+type RecordRequest struct {
+	Limit int `form:"limit"`
+	Page  int `form:"page"`
+}
+
+// SchemaInfo is layout of files of schema, rows of schema are read by key
+type SchemaInfo struct {
+	Name    string   `json:"name"`
+	Key     string   `json:"key"`
+	Columns []string `json:"columns"`
+}
+
+// RowError is invalid field of row of file
+type RowError struct {
+	Line   int    `json:"line"`
+	Field  string `json:"field"`
+	Value  string `json:"value"`
+	Reason string `json:"reason"`
 }
 
 type Files struct {
@@ -28,7 +62,165 @@ type ParsedFiles struct {
 }
 
 type Request struct {
-	UnitGUID string `json:"unit_guid"`
-	Limit    int    `json:"limit"`
-	Page     int    `json:"page"`
+	UnitGUID string `json:"unit_guid" form:"-"`
+	Limit    int    `json:"limit,omitempty" form:"limit"`
+	Page     int    `json:"page,omitempty" form:"page"`
+	Cursor   string `json:"cursor,omitempty" form:"cursor"`
+}
+
+type Error struct {
+	Code    string      `json:"code"`
+	Message string      `json:"message"`
+	Details interface{} `json:"details,omitempty"`
+}
+
+type SearchRequest struct {
+	Filters         map[string]string `form:"-"`
+	LevelMin        *int              `form:"level_min"`
+	LevelMax        *int              `form:"level_max"`
+	MessageIDPrefix string            `form:"message_id_prefix"`
+	Query           string            `form:"q"`
+	Sort            string            `form:"sort"`
+	Fields          string            `form:"fields"`
+	Page            int               `form:"page"`
+	Limit           int               `form:"limit"`
+}
+
+type StatsFilter struct {
+	UnitGUID    string `form:"unit_guid"`
+	InventoryID string `form:"inventory_id"`
+	Limit       int    `form:"limit"`
+}
+
+type Count struct {
+	Key   string `json:"key"`
+	Count int    `json:"count"`
+}
+
+type Stats struct {
+	Total   int     `json:"total"`
+	ByClass []Count `json:"by_class"`
+	ByLevel []Count `json:"by_level"`
+	ByArea  []Count `json:"by_area"`
+	ByFile  []Count `json:"by_file"`
+	ByUnit  []Count `json:"by_unit"`
+}
+
+type CatalogFilter struct {
+	Query string `form:"q"`
+	Page  int    `form:"page"`
+	Limit int    `form:"limit"`
+}
+
+type CatalogItem struct {
+	ID        string    `json:"id"`
+	Rows      int       `json:"rows"`
+	FirstFile string    `json:"first_file"`
+	LastFile  string    `json:"last_file"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+type Sort struct {
+	Field string
+	Desc  bool
+}
+
+type OccurrenceQuery struct {
+	UnitGUID        string
+	Filters         map[string]string
+	LevelMin        *int
+	LevelMax        *int
+	MessageIDPrefix string
+	Text            string
+	Sort            []Sort
+	AfterID         int64
+	Limit           int
+	Offset          int
+}
+
+type Report struct {
+	Name        string
+	ContentType string
+	ETag        string
+	Data        []byte
+	NotModified bool // etag is the same as If-None-Match, report is not rendered
+}
+
+// ReportVersion of rows of report, rows are only appended so count and last id change with them
+type ReportVersion struct {
+	Rows   int
+	LastID int64
+}
+
+const (
+	JobPending  = "pending"
+	JobParsing  = "parsing"
+	JobStored   = "stored"
+	JobRendered = "rendered"
+	JobFailed   = "failed"
+)
+
+type Job struct {
+	ID         string         `json:"id"`
+	File       string         `json:"file"`
+	Status     string         `json:"status"`
+	Rows       int            `json:"rows"`
+	Dropped    map[string]int `json:"dropped,omitempty"` // rows skipped by name of parser filter rule
+	Invalid    int            `json:"invalid,omitempty"` // rows skipped by validation, first of their errors are in RowErrors
+	RowErrors  []RowError     `json:"row_errors,omitempty"`
+	UnitGUIDs  []string       `json:"unit_guids"` // keys of rows, unit guids for occurrence schema
+	Error      string         `json:"error,omitempty"`
+	Outputs    []string       `json:"outputs,omitempty"`
+	CreatedAt  time.Time      `json:"created_at"`
+	StartedAt  *time.Time     `json:"started_at,omitempty"`
+	StoredAt   *time.Time     `json:"stored_at,omitempty"`
+	FinishedAt *time.Time     `json:"finished_at,omitempty"`
+	DurationMs int64          `json:"duration_ms,omitempty"`
+}
+
+type JobFilter struct {
+	Status   string `form:"status"`
+	File     string `form:"file"`
+	UnitGUID string `form:"unit_guid"`
+	Page     int    `form:"page"`
+	Limit    int    `form:"limit"`
+}
+
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	Total      int    `json:"total"`
+	Page       int    `json:"page,omitempty"`
+	PageSize   int    `json:"page_size"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+const (
+	HealthOK   = "ok"
+	HealthFail = "fail"
+)
+
+// Health is result of liveness or readiness check, Status is fail when any check fails
+type Health struct {
+	Status  string        `json:"status"`
+	DB      *DBHealth     `json:"db,omitempty"`
+	Watcher WatcherHealth `json:"watcher"`
+	Worker  WorkerHealth  `json:"worker"`
+}
+
+type DBHealth struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type WatcherHealth struct {
+	Status   string     `json:"status"`
+	Error    string     `json:"error,omitempty"`
+	LastScan *time.Time `json:"last_scan,omitempty"`
+	Backlog  int        `json:"backlog"`
+}
+
+type WorkerHealth struct {
+	LastError   string     `json:"last_error,omitempty"`
+	LastErrorAt *time.Time `json:"last_error_at,omitempty"`
 }
@@ -0,0 +1,24 @@
+package storage
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"goTSVParser/internal/constants"
+	"net"
+)
+
+// unavailable mark errors of lost or refused connection, so api answers 503 instead of 500
+func unavailable(err error) error {
+	var netErr net.Error
+	var pqErr *pq.Error
+	switch {
+	case errors.Is(err, driver.ErrBadConn), errors.Is(err, sql.ErrConnDone), errors.As(err, &netErr):
+	case errors.As(err, &pqErr) && (pqErr.Code.Class() == "08" || pqErr.Code.Class() == "53" || pqErr.Code.Class() == "57"):
+	default:
+		return err
+	}
+	return fmt.Errorf("%w: %w", constants.ErrUnavailable, err)
+}
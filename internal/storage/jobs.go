@@ -0,0 +1,183 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"goTSVParser/internal/constants"
+	"goTSVParser/internal/shema"
+	"strconv"
+	"strings"
+)
+
+const jobColumns = "id, file, status, rows, dropped, invalid, row_errors, unit_guids, COALESCE(error, ''), created_at, " +
+	"started_at, stored_at, finished_at"
+
+// CreateJob save job of file which is not picked up by watcher yet
+func (s *DBStorage) CreateJob(ctx context.Context, job shema.Job) error {
+	insertQuery := `INSERT INTO jobs(id, file, status) VALUES ($1, $2, $3)`
+	_, err := s.conn.ExecContext(ctx, insertQuery, job.ID, job.File, job.Status)
+	if err != nil {
+		return fmt.Errorf("failed to save job in db: %w", unavailable(err))
+	}
+	return nil
+}
+
+// StartJob mark file as parsing, return id of existing job of file or given id for new one
+func (s *DBStorage) StartJob(ctx context.Context, id string, file string) (string, error) {
+	upsertQuery := `INSERT INTO jobs(id, file, status, started_at) VALUES ($1, $2, $3, now())
+		ON CONFLICT (file) DO UPDATE SET status = EXCLUDED.status, rows = 0, dropped = '{}', invalid = 0, row_errors = '[]', unit_guids = '{}', error = NULL,
+		started_at = now(), stored_at = NULL, finished_at = NULL, updated_at = now()
+		RETURNING id`
+	var jobID string
+	err := s.conn.QueryRowContext(ctx, upsertQuery, id, file, shema.JobParsing).Scan(&jobID)
+	if err != nil {
+		return "", fmt.Errorf("failed to start job in db: %w", unavailable(err))
+	}
+	return jobID, nil
+}
+
+// UpdateJob save status, counters and error of job
+func (s *DBStorage) UpdateJob(ctx context.Context, job shema.Job) error {
+	updateQuery := `UPDATE jobs SET status = $2, rows = $3, unit_guids = $4, error = NULLIF($5, ''), updated_at = now(),
+		stored_at = CASE WHEN $6 THEN now() ELSE stored_at END,
+		finished_at = CASE WHEN $7 THEN now() ELSE finished_at END,
+		dropped = $8, invalid = $9, row_errors = $10
+		WHERE id = $1`
+	guids := job.UnitGUIDs
+	if guids == nil {
+		guids = []string{}
+	}
+	dropped, err := json.Marshal(job.Dropped)
+	if err != nil {
+		return err
+	}
+	if job.Dropped == nil {
+		dropped = []byte("{}")
+	}
+	rowErrors, err := json.Marshal(job.RowErrors)
+	if err != nil {
+		return err
+	}
+	if job.RowErrors == nil {
+		rowErrors = []byte("[]")
+	}
+	stored := job.Status == shema.JobStored
+	finished := job.Status == shema.JobRendered || job.Status == shema.JobFailed
+	_, err = s.conn.ExecContext(ctx, updateQuery, job.ID, job.Status, job.Rows, pq.Array(guids), job.Error, stored, finished,
+		string(dropped), job.Invalid, string(rowErrors))
+	if err != nil {
+		return fmt.Errorf("failed to update job in db: %w", unavailable(err))
+	}
+	return nil
+}
+
+// GetJob get job by id
+func (s *DBStorage) GetJob(ctx context.Context, id string) (shema.Job, error) {
+	row := s.conn.QueryRowContext(ctx, "SELECT "+jobColumns+" FROM jobs WHERE id = $1", id)
+	job, err := scanJob(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return shema.Job{}, constants.ErrNotFound
+	}
+	if err != nil {
+		return shema.Job{}, fmt.Errorf("failed to get job from db: %w", unavailable(err))
+	}
+	return job, nil
+}
+
+// GetJobs get page of jobs matching filter, newest first, and total count of matching jobs
+func (s *DBStorage) GetJobs(ctx context.Context, f shema.JobFilter) ([]shema.Job, int, error) {
+	var where []string
+	var args []interface{}
+	if f.Status != "" {
+		args = append(args, f.Status)
+		where = append(where, "status = $"+strconv.Itoa(len(args)))
+	}
+	if f.File != "" {
+		args = append(args, "%"+escapeLike(f.File)+"%")
+		where = append(where, "file ILIKE $"+strconv.Itoa(len(args)))
+	}
+	if f.UnitGUID != "" {
+		args = append(args, f.UnitGUID)
+		where = append(where, "$"+strconv.Itoa(len(args))+" = ANY(unit_guids)")
+	}
+	cond := ""
+	if len(where) > 0 {
+		cond = " WHERE " + strings.Join(where, " AND ")
+	}
+
+	var total int
+	err := s.conn.QueryRowContext(ctx, "SELECT count(*) FROM jobs"+cond, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count jobs: %w", unavailable(err))
+	}
+
+	args = append(args, f.Limit, (f.Page-1)*f.Limit)
+	query := "SELECT " + jobColumns + " FROM jobs" + cond +
+		fmt.Sprintf(" ORDER BY created_at DESC, id LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+	rows, err := s.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get jobs: %w", unavailable(err))
+	}
+	defer rows.Close()
+
+	var jobs []shema.Job
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("scan error: %w", err)
+		}
+		jobs = append(jobs, job)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error rows: %w", err)
+	}
+	return jobs, total, nil
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanJob(row scanner) (shema.Job, error) {
+	var job shema.Job
+	var startedAt, storedAt, finishedAt sql.NullTime
+	var dropped, rowErrors []byte
+	err := row.Scan(&job.ID, &job.File, &job.Status, &job.Rows, &dropped, &job.Invalid, &rowErrors, pq.Array(&job.UnitGUIDs), &job.Error,
+		&job.CreatedAt, &startedAt, &storedAt, &finishedAt)
+	if err != nil {
+		return shema.Job{}, err
+	}
+	if err = json.Unmarshal(dropped, &job.Dropped); err != nil {
+		return shema.Job{}, err
+	}
+	if len(job.Dropped) == 0 {
+		job.Dropped = nil
+	}
+	if err = json.Unmarshal(rowErrors, &job.RowErrors); err != nil {
+		return shema.Job{}, err
+	}
+	if len(job.RowErrors) == 0 {
+		job.RowErrors = nil
+	}
+	if startedAt.Valid {
+		job.StartedAt = &startedAt.Time
+	}
+	if storedAt.Valid {
+		job.StoredAt = &storedAt.Time
+	}
+	if finishedAt.Valid {
+		job.FinishedAt = &finishedAt.Time
+		if startedAt.Valid {
+			job.DurationMs = finishedAt.Time.Sub(startedAt.Time).Milliseconds()
+		}
+	}
+	return job, nil
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
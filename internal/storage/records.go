@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"goTSVParser/config"
+	"goTSVParser/internal/constants"
+	"goTSVParser/internal/shema"
+	"strings"
+)
+
+// recordColumns of table of schema as text, table and columns are validated identifiers, see config.Validate
+func recordColumns(schema config.Schema) string {
+	columns := []string{"id"}
+	for _, c := range schema.Columns {
+		columns = append(columns, "COALESCE("+c.DBColumn()+"::text, '')")
+	}
+	return strings.Join(append(columns, "COALESCE(file, '')"), ", ")
+}
+
+// keyColumn is db column of key of schema
+func keyColumn(schema config.Schema) string {
+	for _, c := range schema.Columns {
+		if c.Name == schema.Key {
+			return c.DBColumn()
+		}
+	}
+	return schema.Key
+}
+
+// GetReportVersion count rows of key in table of schema and find the last one, rows are not loaded
+func (s *DBStorage) GetReportVersion(ctx context.Context, schema config.Schema, key string) (shema.ReportVersion, error) {
+	var v shema.ReportVersion
+	query := "SELECT count(*), COALESCE(max(id), 0) FROM " + schema.Table + " WHERE " + keyColumn(schema) + " = $1"
+	err := s.conn.QueryRowContext(ctx, query, key).Scan(&v.Rows, &v.LastID)
+	if err != nil {
+		return v, fmt.Errorf("failed to count rows: %w", unavailable(err))
+	}
+	if v.Rows == 0 {
+		return v, fmt.Errorf("%w: no rows of %s with key %s", constants.ErrNotFound, schema.Name, key)
+	}
+	return v, nil
+}
+
+// GetRecords get rows of key in table of schema up to last id
+func (s *DBStorage) GetRecords(ctx context.Context, schema config.Schema, key string, lastID int64) ([]shema.Record, error) {
+	query := "SELECT " + recordColumns(schema) + " FROM " + schema.Table + " WHERE " + keyColumn(schema) + " = $1 AND id <= $2 ORDER BY id"
+	return s.queryRecords(ctx, schema, query, key, lastID)
+}
+
+// GetRecordsPage get page of rows of key in table of schema and count of all of them
+func (s *DBStorage) GetRecordsPage(ctx context.Context, schema config.Schema, key string, limit, offset int) ([]shema.Record, int, error) {
+	where := " FROM " + schema.Table + " WHERE " + keyColumn(schema) + " = $1"
+	var total int
+	err := s.conn.QueryRowContext(ctx, "SELECT count(*)"+where, key).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count rows: %w", unavailable(err))
+	}
+	if total == 0 {
+		return nil, 0, nil
+	}
+	records, err := s.queryRecords(ctx, schema, "SELECT "+recordColumns(schema)+where+" ORDER BY id LIMIT $2 OFFSET $3", key, limit, offset)
+	return records, total, err
+}
+
+func (s *DBStorage) queryRecords(ctx context.Context, schema config.Schema, query string, args ...interface{}) ([]shema.Record, error) {
+	rows, err := s.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, unavailable(err)
+	}
+	defer rows.Close()
+
+	var records []shema.Record
+	for rows.Next() {
+		r := shema.Record{Values: make([]string, len(schema.Columns))}
+		dest := []interface{}{&r.ID}
+		for i := range r.Values {
+			dest = append(dest, &r.Values[i])
+		}
+		if err = rows.Scan(append(dest, &r.File)...); err != nil {
+			return nil, fmt.Errorf("error put in struct: %w", err)
+		}
+		for i, c := range schema.Columns {
+			if c.Name == schema.Key {
+				r.Key = r.Values[i]
+			}
+		}
+		records = append(records, r)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error rows: %w", err)
+	}
+	return records, nil
+}
@@ -3,12 +3,21 @@ package storage
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	"fmt"
+	"github.com/XSAM/otelsql"
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
 	"goTSVParser/config"
+	"goTSVParser/internal/metrics"
 	"goTSVParser/internal/shema"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type DBStorage struct {
@@ -16,7 +25,16 @@ type DBStorage struct {
 }
 
 func NewDBStorage(config config.Config) (*DBStorage, error) {
-	db, err := sql.Open("postgres", config.DB)
+	// queries are traced only as part of traced file or request
+	db, err := otelsql.Open("postgres", config.DB,
+		otelsql.WithAttributes(semconv.DBSystemPostgreSQL),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{
+			OmitConnResetSession: true,
+			OmitRows:             true,
+			SpanFilter: func(ctx context.Context, _ otelsql.Method, _ string, _ []driver.NamedValue) bool {
+				return trace.SpanContextFromContext(ctx).IsValid()
+			},
+		}))
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to db %w", err)
 	}
@@ -38,45 +56,105 @@ func NewDBStorage(config config.Config) (*DBStorage, error) {
 		conn: db,
 	}
 
-	return s, s.CheckConnection()
+	return s, s.CheckConnection(context.Background())
 }
 
-func (s *DBStorage) CheckConnection() error {
-	if err := s.conn.Ping(); err != nil {
-		return fmt.Errorf("failed to connect to db %w", err)
+// CheckConnection ping db
+func (s *DBStorage) CheckConnection(ctx context.Context) error {
+	if err := s.conn.PingContext(ctx); err != nil {
+		return unavailable(fmt.Errorf("failed to connect to db %w", err))
 	}
 	return nil
 }
 
 // SaveFilesWithErr save files only with err
-func (s *DBStorage) SaveFilesWithErr(sh shema.Files) error {
+func (s *DBStorage) SaveFilesWithErr(ctx context.Context, sh shema.Files) error {
 	insertQuery := `INSERT INTO checkedFilesWithErr(name, error) VALUES ($1, $2) ON CONFLICT (name) DO NOTHING`
-	_, err := s.conn.Exec(insertQuery, sh.File, sh.Err)
+	_, err := s.conn.ExecContext(ctx, insertQuery, sh.File, sh.Err)
 	if err != nil {
-		return fmt.Errorf("failed to save file with err in db %w", err)
+		return fmt.Errorf("failed to save file with err in db %w", unavailable(err))
 	}
 	return nil
 }
 
 // SaveFiles save files without err
-func (s *DBStorage) SaveFiles(fileName string) error {
+func (s *DBStorage) SaveFiles(ctx context.Context, fileName string) error {
 	insertQuery := `INSERT INTO checkedFiles(name) VALUES ($1) ON CONFLICT (name) DO NOTHING`
-	_, err := s.conn.Exec(insertQuery, fileName)
+	_, err := s.conn.ExecContext(ctx, insertQuery, fileName)
 	if err != nil {
-		return fmt.Errorf("failed to save file in db %w", err)
+		return fmt.Errorf("failed to save file in db %w", unavailable(err))
 	}
 	return nil
 }
 
-// Save saveInfo from file
-func (s *DBStorage) Save(sh shema.Tsv) error {
-	insertQuery := `INSERT INTO occurrence(number, mqtt, inventoryid, unitguid, messageid, messagetext, context, messageclass, 
-                level, area, address, block, type, bit, invertbit) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)`
-	_, err := s.conn.Exec(insertQuery, sh.Number, sh.MQTT, sh.InventoryID, sh.UnitGUID, sh.MessageID, sh.MessageText, sh.Context, sh.MessageClass, sh.Level,
-		sh.Area, sh.Address, sh.Block, sh.Type, sh.Bit, sh.InvertBit)
+// Save save batch of rows of file into table of schema with one insert
+func (s *DBStorage) Save(ctx context.Context, schema config.Schema, rows []shema.Record) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(schema.Columns)+1)
+	for _, c := range schema.Columns {
+		names = append(names, c.DBColumn())
+	}
+	names = append(names, "file")
+	columns := len(names)
+
+	values := make([]string, 0, len(rows))
+	args := make([]interface{}, 0, len(rows)*columns)
+	for i, row := range rows {
+		params := make([]string, columns)
+		for j := range params {
+			params[j] = "$" + strconv.Itoa(i*columns+j+1)
+		}
+		values = append(values, "("+strings.Join(params, ", ")+")")
+		for j, c := range schema.Columns {
+			args = append(args, value(c, row.Values[j]))
+		}
+		args = append(args, row.File)
+	}
+	// table and columns of schema are validated identifiers, see config.Validate
+	insertQuery := "INSERT INTO " + schema.Table + "(" + strings.Join(names, ", ") + ") VALUES " + strings.Join(values, ", ")
+
+	start := time.Now()
+	_, err := s.conn.ExecContext(ctx, insertQuery, args...)
+	metrics.Since(metrics.SaveDuration, start)
 
 	if err != nil {
-		return fmt.Errorf("failed to save in db: %v", err)
+		return fmt.Errorf("failed to save in db: %w", unavailable(err))
+	}
+	metrics.RowsSaved.Add(float64(len(rows)))
+	return nil
+}
+
+// sqlTypes of schema column types
+var sqlTypes = map[string]string{
+	"":               "VARCHAR(255)",
+	shema.KindString: "VARCHAR(255)",
+	shema.KindText:   "TEXT",
+	shema.KindInt:    "INTEGER",
+	shema.KindBool:   "BOOLEAN",
+	shema.KindGUID:   "VARCHAR(36)",
+}
+
+// CreateTable create table of schema with index on key column, existing table is kept as is
+func (s *DBStorage) CreateTable(ctx context.Context, schema config.Schema) error {
+	columns := []string{"id SERIAL PRIMARY KEY"}
+	key := ""
+	for _, c := range schema.Columns {
+		columns = append(columns, c.DBColumn()+" "+sqlTypes[c.Type])
+		if c.Name == schema.Key {
+			key = c.DBColumn()
+		}
+	}
+	columns = append(columns, "file TEXT", "created_at TIMESTAMPTZ NOT NULL DEFAULT now()")
+
+	query := "CREATE TABLE IF NOT EXISTS " + schema.Table + " (" + strings.Join(columns, ", ") + ")"
+	if _, err := s.conn.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("failed to create table %s: %w", schema.Table, err)
+	}
+	query = "CREATE INDEX IF NOT EXISTS " + schema.Table + "_" + key + "_id_idx ON " + schema.Table + " (" + key + ", id)"
+	if _, err := s.conn.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("failed to create index of %s: %w", schema.Table, err)
 	}
 	return nil
 }
@@ -101,21 +179,219 @@ func (s *DBStorage) GetCheckedFiles() ([]shema.ParsedFiles, error) {
 	return files, nil
 }
 
-// GetAllGuids get data from db
-func (s *DBStorage) GetAllGuids(ctx context.Context, unitGuid string) ([]shema.Tsv, error) {
-	query := "SELECT number, mqtt, inventoryid, unitguid, messageid, messagetext, context, " +
-		"messageclass, level, area, address, block, type, bit, invertbit FROM occurrence WHERE unitguid = $1"
-	rows, err := s.conn.QueryContext(ctx, query, unitGuid)
+// value of column, empty value of typed column is null
+func value(c config.Column, v string) interface{} {
+	if v == "" && (c.Type == shema.KindInt || c.Type == shema.KindBool) {
+		return nil
+	}
+	return v
+}
+
+const occurrenceColumns = "id, COALESCE(number::text, ''), mqtt, inventoryid, unitguid, messageid, messagetext, context, " +
+	"messageclass, COALESCE(level::text, ''), area, address, block, type, COALESCE(bit::text, ''), COALESCE(invertbit::text, ''), " +
+	"COALESCE(file, '')"
+
+// GetOccurrences get page of data matching query and total count of matching rows
+func (s *DBStorage) GetOccurrences(ctx context.Context, q shema.OccurrenceQuery) ([]shema.Tsv, int, error) {
+	where, args := occurrenceWhere(q)
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	var total int
+	err := s.conn.QueryRowContext(ctx, "SELECT count(*) FROM occurrence"+whereClause(where), args...).Scan(&total)
 	if err != nil {
-		return nil, err
+		return nil, 0, fmt.Errorf("failed to count rows: %w", unavailable(err))
+	}
+
+	if q.AfterID > 0 {
+		where = append(where, "id > "+arg(q.AfterID))
+	}
+
+	var order []string
+	for _, o := range q.Sort {
+		expr := column(o.Field)
+		if o.Desc {
+			expr += " DESC NULLS LAST"
+		}
+		order = append(order, expr)
+	}
+	order = append(order, "id")
+
+	query := "SELECT " + occurrenceColumns + " FROM occurrence" + whereClause(where) + " ORDER BY " + strings.Join(order, ", ") +
+		" LIMIT " + arg(q.Limit) + " OFFSET " + arg(q.Offset)
+	data, err := s.queryOccurrences(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	return data, total, nil
+}
+
+// occurrenceWhere build conditions of query filters
+func occurrenceWhere(q shema.OccurrenceQuery) ([]string, []interface{}) {
+	var where []string
+	var args []interface{}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	if q.UnitGUID != "" {
+		where = append(where, "unitguid = "+arg(q.UnitGUID))
+	}
+	fields := make([]string, 0, len(q.Filters))
+	for field := range q.Filters {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		where = append(where, column(field)+" = "+arg(q.Filters[field]))
+	}
+	if q.LevelMin != nil {
+		where = append(where, "level >= "+arg(*q.LevelMin))
+	}
+	if q.LevelMax != nil {
+		where = append(where, "level <= "+arg(*q.LevelMax))
+	}
+	if q.MessageIDPrefix != "" {
+		where = append(where, "messageid LIKE "+arg(escapeLike(q.MessageIDPrefix)+"%"))
+	}
+	if q.Text != "" {
+		where = append(where, "to_tsvector('russian', messagetext) @@ websearch_to_tsquery('russian', "+arg(q.Text)+")")
+	}
+	return where, args
+}
+
+func whereClause(where []string) string {
+	if len(where) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(where, " AND ")
+}
+
+// GetStats count rows matching query grouped by class, level, area, file and unit, limit is max count of groups
+func (s *DBStorage) GetStats(ctx context.Context, q shema.OccurrenceQuery, limit int) (shema.Stats, error) {
+	where, args := occurrenceWhere(q)
+	cond := whereClause(where)
+
+	var stats shema.Stats
+	err := s.conn.QueryRowContext(ctx, "SELECT count(*) FROM occurrence"+cond, args...).Scan(&stats.Total)
+	if err != nil {
+		return shema.Stats{}, fmt.Errorf("failed to count rows: %w", unavailable(err))
+	}
+
+	groups := []struct {
+		field  string
+		counts *[]shema.Count
+	}{
+		{"MessageClass", &stats.ByClass},
+		{"Level", &stats.ByLevel},
+		{"Area", &stats.ByArea},
+		{"File", &stats.ByFile},
+		{"UnitGUID", &stats.ByUnit},
+	}
+	for _, g := range groups {
+		query := fmt.Sprintf("SELECT COALESCE(%s, ''), count(*) FROM occurrence%s GROUP BY 1 ORDER BY 2 DESC, 1 LIMIT $%d",
+			textColumn(g.field), cond, len(args)+1)
+		*g.counts, err = s.countBy(ctx, query, append(args, limit)...)
+		if err != nil {
+			return shema.Stats{}, fmt.Errorf("failed to count rows by %s: %w", g.field, unavailable(err))
+		}
+	}
+	return stats, nil
+}
+
+// GetCatalog get page of distinct values of field with row count, first and last seen file and time
+func (s *DBStorage) GetCatalog(ctx context.Context, field string, f shema.CatalogFilter) ([]shema.CatalogItem, int, error) {
+	col := column(field)
+	where := []string{col + " <> ''"}
+	var args []interface{}
+	if f.Query != "" {
+		args = append(args, "%"+escapeLike(f.Query)+"%")
+		where = append(where, col+" ILIKE $1")
+	}
+	cond := whereClause(where)
+
+	var total int
+	err := s.conn.QueryRowContext(ctx, "SELECT count(DISTINCT "+col+") FROM occurrence"+cond, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count %s: %w", field, unavailable(err))
+	}
+
+	args = append(args, f.Limit, (f.Page-1)*f.Limit)
+	query := fmt.Sprintf(`WITH page AS (
+		SELECT %[1]s AS key, count(*) AS rows, min(id) AS first_id, max(id) AS last_id,
+		       min(created_at) AS first_seen, max(created_at) AS last_seen
+		FROM occurrence%[2]s GROUP BY %[1]s ORDER BY %[1]s LIMIT $%[3]d OFFSET $%[4]d)
+		SELECT p.key, p.rows, COALESCE(f.file, ''), COALESCE(l.file, ''), p.first_seen, p.last_seen
+		FROM page p JOIN occurrence f ON f.id = p.first_id JOIN occurrence l ON l.id = p.last_id
+		ORDER BY p.key`, col, cond, len(args)-1, len(args))
+	rows, err := s.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get %s: %w", field, unavailable(err))
+	}
+	defer rows.Close()
+
+	items := []shema.CatalogItem{}
+	for rows.Next() {
+		var item shema.CatalogItem
+		err = rows.Scan(&item.ID, &item.Rows, &item.FirstFile, &item.LastFile, &item.FirstSeen, &item.LastSeen)
+		if err != nil {
+			return nil, 0, fmt.Errorf("scan error: %w", err)
+		}
+		items = append(items, item)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error rows: %w", err)
+	}
+	return items, total, nil
+}
+
+func (s *DBStorage) countBy(ctx context.Context, query string, args ...interface{}) ([]shema.Count, error) {
+	rows, err := s.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, unavailable(err)
+	}
+	defer rows.Close()
+
+	counts := []shema.Count{}
+	for rows.Next() {
+		var c shema.Count
+		if err := rows.Scan(&c.Key, &c.Count); err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		counts = append(counts, c)
+	}
+	return counts, rows.Err()
+}
+
+// column of Tsv field, fields are validated with shema.TsvField before
+func column(field string) string {
+	return strings.ToLower(field)
+}
+
+// textColumn is column of Tsv field as text, e.g. level::text
+func textColumn(field string) string {
+	switch shema.TsvKind(field) {
+	case shema.KindInt, shema.KindBool:
+		return column(field) + "::text"
+	}
+	return column(field)
+}
+
+func (s *DBStorage) queryOccurrences(ctx context.Context, query string, args ...interface{}) ([]shema.Tsv, error) {
+	rows, err := s.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, unavailable(err)
 	}
 	defer rows.Close()
 
 	var data []shema.Tsv
 	for rows.Next() {
 		var d shema.Tsv
-		err = rows.Scan(&d.Number, &d.MQTT, &d.InventoryID, &d.UnitGUID, &d.MessageID, &d.MessageText, &d.Context, &d.MessageClass,
-			&d.Level, &d.Area, &d.Address, &d.Block, &d.Type, &d.Bit, &d.InvertBit)
+		err = rows.Scan(&d.ID, &d.Number, &d.MQTT, &d.InventoryID, &d.UnitGUID, &d.MessageID, &d.MessageText, &d.Context, &d.MessageClass,
+			&d.Level, &d.Area, &d.Address, &d.Block, &d.Type, &d.Bit, &d.InvertBit, &d.File)
 		if err != nil {
 			return nil, fmt.Errorf("error put in struct: %w", err)
 		}
@@ -125,10 +401,6 @@ func (s *DBStorage) GetAllGuids(ctx context.Context, unitGuid string) ([]shema.T
 	if err = rows.Err(); err != nil {
 		return nil, fmt.Errorf("error rows: %w", err)
 	}
-	if len(data) == 0 {
-		return nil, fmt.Errorf("no rows found with the provided unitguid: %s", unitGuid)
-	}
-
 	return data, nil
 }
 
@@ -0,0 +1,239 @@
+package workers
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/bzip2"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"github.com/klauspost/compress/zstd"
+	"go.uber.org/zap"
+	"goTSVParser/internal/logging"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+)
+
+// MemberSep separate path of archive and path of member in name of logical file, e.g. from/units.zip!/a.tsv
+const MemberSep = "!/"
+
+const (
+	compressionGzip  = "gzip"
+	compressionZstd  = "zstd"
+	compressionBzip2 = "bzip2"
+)
+
+var compressions = map[string]string{
+	".gz":  compressionGzip,
+	".zst": compressionZstd,
+	".bz2": compressionBzip2,
+}
+
+// Decompressed return name without extension of compression and the compression, e.g. a.tsv and gzip for a.tsv.gz
+func Decompressed(name string) (string, string) {
+	ext := path.Ext(name)
+	base := name[:len(name)-len(ext)]
+	if strings.EqualFold(ext, ".tgz") {
+		return base + ".tar", compressionGzip
+	}
+	if c, ok := compressions[strings.ToLower(ext)]; ok {
+		return base, c
+	}
+	return name, ""
+}
+
+// IsArchive tell if file is zip or tar archive, tar may be compressed
+func IsArchive(name string) bool {
+	if strings.Contains(name, MemberSep) {
+		return false
+	}
+	base, c := Decompressed(name)
+	switch strings.ToLower(path.Ext(base)) {
+	case ".tar":
+		return true
+	case ".zip":
+		return c == ""
+	}
+	return false
+}
+
+// Members list files of archive as names of logical files, directories and hidden files are skipped.
+// Members with invalid paths, e.g. ../a.tsv or /a.tsv, can't be opened and are skipped with warning
+func Members(archive string, logger *zap.Logger) ([]string, error) {
+	base, _ := Decompressed(archive)
+	if strings.EqualFold(path.Ext(base), ".zip") {
+		return zipMembers(archive, logger)
+	}
+	return tarMembers(archive, logger)
+}
+
+// member tell if entry of archive is listed, it is not hidden and has valid path
+func member(archive, name string, logger *zap.Logger) bool {
+	if !fs.ValidPath(name) {
+		logger.Warn("archive member skipped, bad path", logging.File(archive), zap.String("member", name))
+		return false
+	}
+	return !hidden(name)
+}
+
+// errMemberNotFound is returned when archive has no such member, it may be removed since listing
+var errMemberNotFound = errors.New("archive member not found")
+
+// openFile open logical file for reading, members of archives and compressed files are read decompressed
+func openFile(name string) (io.ReadCloser, error) {
+	archive, member, ok := strings.Cut(name, MemberSep)
+	if !ok {
+		file, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		return decompress(file, name, file)
+	}
+
+	r, err := openMember(archive, member)
+	if err != nil {
+		return nil, err
+	}
+	return decompress(r, member, r)
+}
+
+// openMember of archive, member is streamed while archive is open
+func openMember(archive, member string) (io.ReadCloser, error) {
+	base, _ := Decompressed(archive)
+	if strings.EqualFold(path.Ext(base), ".zip") {
+		zr, err := zip.OpenReader(archive)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open zip: %w", err)
+		}
+		f, err := zr.Open(member)
+		if err != nil {
+			zr.Close()
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil, fmt.Errorf("%w: %s", errMemberNotFound, member)
+			}
+			return nil, fmt.Errorf("failed to open %s: %w", member, err)
+		}
+		return readCloser{f, func() error {
+			f.Close()
+			return zr.Close()
+		}}, nil
+	}
+
+	file, err := os.Open(archive)
+	if err != nil {
+		return nil, err
+	}
+	r, err := decompress(file, archive, file)
+	if err != nil {
+		return nil, err
+	}
+	tr := tar.NewReader(r)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			r.Close()
+			return nil, fmt.Errorf("%w: %s", errMemberNotFound, member)
+		}
+		if err != nil {
+			r.Close()
+			return nil, fmt.Errorf("failed to read tar: %w", err)
+		}
+		if h.Typeflag == tar.TypeReg && h.Name == member {
+			return readCloser{tr, r.Close}, nil
+		}
+	}
+}
+
+// decompress r by extension of name, closer is closed with result
+func decompress(r io.Reader, name string, closer io.Closer) (io.ReadCloser, error) {
+	var (
+		dr  io.Reader
+		err error
+	)
+	switch _, c := Decompressed(name); c {
+	case "":
+		return readCloser{r, closer.Close}, nil
+	case compressionGzip:
+		dr, err = gzip.NewReader(r)
+	case compressionBzip2:
+		dr = bzip2.NewReader(r)
+	case compressionZstd:
+		var zr *zstd.Decoder
+		zr, err = zstd.NewReader(r)
+		if err == nil {
+			return readCloser{zr, func() error {
+				zr.Close()
+				return closer.Close()
+			}}, nil
+		}
+	}
+	if err != nil {
+		closer.Close()
+		return nil, fmt.Errorf("failed to decompress %s: %w", name, err)
+	}
+	return readCloser{dr, closer.Close}, nil
+}
+
+type readCloser struct {
+	io.Reader
+	close func() error
+}
+
+func (r readCloser) Close() error {
+	return r.close()
+}
+
+func zipMembers(archive string, logger *zap.Logger) ([]string, error) {
+	zr, err := zip.OpenReader(archive)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open zip: %w", err)
+	}
+	defer zr.Close()
+	var names []string
+	for _, f := range zr.File {
+		if !f.FileInfo().IsDir() && member(archive, f.Name, logger) {
+			names = append(names, archive+MemberSep+f.Name)
+		}
+	}
+	return names, nil
+}
+
+func tarMembers(archive string, logger *zap.Logger) ([]string, error) {
+	file, err := os.Open(archive)
+	if err != nil {
+		return nil, err
+	}
+	r, err := decompress(file, archive, file)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var names []string
+	tr := tar.NewReader(r)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return names, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read tar: %w", err)
+		}
+		if h.Typeflag == tar.TypeReg && member(archive, h.Name, logger) {
+			names = append(names, archive+MemberSep+h.Name)
+		}
+	}
+}
+
+// hidden member of archive, e.g. .DS_Store or __MACOSX/a.tsv
+func hidden(name string) bool {
+	for _, part := range strings.Split(name, "/") {
+		if strings.HasPrefix(part, ".") || part == "__MACOSX" {
+			return true
+		}
+	}
+	return false
+}
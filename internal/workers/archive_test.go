@@ -0,0 +1,172 @@
+package workers
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"github.com/klauspost/compress/zstd"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+// bzip2 of "a\t1\n", there is no bzip2 writer in standard library
+const bzip2Data = "\x42\x5a\x68\x39\x31\x41\x59\x26\x53\x59\x1d\x10\x1c\x17\x00\x00\x01\xc9\x00\x00\x30\x20\x00\x20\x00\x20" +
+	"\x00\x21\x9a\x68\x33\x4d\x17\x3c\x5d\xc9\x14\xe1\x42\x40\x74\x40\x70\x5c"
+
+func gzipOf(t *testing.T, data string) string {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	w.Write([]byte(data))
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func zstdOf(t *testing.T, data string) string {
+	w, err := zstd.NewWriter(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	return string(w.EncodeAll([]byte(data), nil))
+}
+
+func zipOf(t *testing.T, files map[string]string) string {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for name, data := range files {
+		f, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Write([]byte(data))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func tarOf(t *testing.T, files map[string]string) string {
+	var buf bytes.Buffer
+	w := tar.NewWriter(&buf)
+	for name, data := range files {
+		if err := w.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(data)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(data))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for name, data := range files {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestOpenFile(t *testing.T) {
+	dir := t.TempDir()
+	row := "a\t1\n"
+	writeFiles(t, dir, map[string]string{
+		"a.tsv":     row,
+		"a.tsv.gz":  gzipOf(t, row),
+		"a.tsv.zst": zstdOf(t, row),
+		"a.tsv.bz2": bzip2Data,
+		"bad.gz":    "not a gzip stream",
+		"a.zip":     zipOf(t, map[string]string{"dir/a.tsv": row, "b.tsv.gz": gzipOf(t, row)}),
+		"a.tgz":     gzipOf(t, tarOf(t, map[string]string{"a.tsv": row})),
+	})
+	tests := []struct {
+		name    string
+		file    string
+		wantErr error
+	}{
+		{name: "OK#1", file: "a.tsv"},
+		{name: "OK#2", file: "a.tsv.gz"},
+		{name: "OK#3", file: "a.tsv.zst"},
+		{name: "OK#4", file: "a.tsv.bz2"},
+		{name: "OK#5", file: "a.zip!/dir/a.tsv"},
+		{name: "OK#6", file: "a.zip!/b.tsv.gz"},
+		{name: "OK#7", file: "a.tgz!/a.tsv"},
+		{name: "BAD#1", file: "a.zip!/c.tsv", wantErr: errMemberNotFound},
+		{name: "BAD#2", file: "a.tgz!/c.tsv", wantErr: errMemberNotFound},
+		{name: "BAD#3", file: "bad.gz", wantErr: gzip.ErrHeader},
+		{name: "BAD#4", file: "none.tsv", wantErr: os.ErrNotExist},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := openFile(filepath.Join(dir, tt.file))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			defer r.Close()
+			data, err := io.ReadAll(r)
+			if err != nil || string(data) != row {
+				t.Errorf("got %q and %v, want %q", data, err, row)
+			}
+		})
+	}
+}
+
+func TestMembers(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{"a.tsv": "", "dir/b.tsv": "", ".hidden": "", "__MACOSX/a.tsv": "", "../up.tsv": "", "/root.tsv": ""}
+	writeFiles(t, dir, map[string]string{
+		"a.zip":     zipOf(t, files),
+		"a.tar.zst": zstdOf(t, tarOf(t, files)),
+		"bad.zip":   "not a zip",
+	})
+	tests := []struct {
+		name     string
+		file     string
+		want     []string
+		wantWarn int
+		wantErr  bool
+	}{
+		{name: "OK#1", file: "a.zip", want: []string{"a.tsv", "dir/b.tsv"}, wantWarn: 2},
+		{name: "OK#2", file: "a.tar.zst", want: []string{"a.tsv", "dir/b.tsv"}, wantWarn: 2},
+		{name: "BAD#1", file: "bad.zip", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			archive := filepath.Join(dir, tt.file)
+			core, logs := observer.New(zap.WarnLevel)
+			got, err := Members(archive, zap.New(core))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got %v, want err %v", err, tt.wantErr)
+			}
+			var want []string
+			for _, name := range tt.want {
+				want = append(want, archive+MemberSep+name)
+			}
+			// members are in order of archive, map of test files has none
+			slices.Sort(got)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("got %v, want %v", got, want)
+			}
+			if logs.Len() != tt.wantWarn {
+				t.Errorf("got %d warnings, want %d", logs.Len(), tt.wantWarn)
+			}
+		})
+	}
+}
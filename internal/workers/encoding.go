@@ -0,0 +1,105 @@
+package workers
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+	"io"
+	"unicode/utf8"
+)
+
+const (
+	EncodingAuto    = "auto"
+	EncodingUTF8    = "utf-8"
+	EncodingCP1251  = "cp1251"
+	EncodingKOI8R   = "koi8-r"
+	EncodingUTF16LE = "utf-16le"
+	EncodingUTF16BE = "utf-16be"
+)
+
+var encodings = map[string]encoding.Encoding{
+	EncodingUTF8:    encoding.Nop,
+	EncodingCP1251:  charmap.Windows1251,
+	"windows-1251":  charmap.Windows1251,
+	EncodingKOI8R:   charmap.KOI8R,
+	EncodingUTF16LE: unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM),
+	EncodingUTF16BE: unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM),
+}
+
+var boms = []struct {
+	bom      []byte
+	encoding string
+}{
+	{[]byte{0xEF, 0xBB, 0xBF}, EncodingUTF8},
+	{[]byte{0xFF, 0xFE}, EncodingUTF16LE},
+	{[]byte{0xFE, 0xFF}, EncodingUTF16BE},
+}
+
+// sniffSize is size of head of file encoding is detected by
+const sniffSize = 4096
+
+// Decode convert text to utf-8 from encoding, which is detected when it is empty or auto.
+// BOM is dropped and wins over configured encoding. Name of source encoding is returned
+func Decode(r io.Reader, name string) (io.Reader, string, error) {
+	br := bufio.NewReaderSize(r, sniffSize)
+	head, _ := br.Peek(sniffSize)
+	for _, b := range boms {
+		if bytes.HasPrefix(head, b.bom) {
+			br.Discard(len(b.bom))
+			name = b.encoding
+			break
+		}
+	}
+	if name == "" || name == EncodingAuto {
+		name = detect(head)
+	}
+	enc, ok := encodings[name]
+	if !ok {
+		return nil, "", fmt.Errorf("unknown encoding %q", name)
+	}
+	if enc == encoding.Nop {
+		return br, name, nil
+	}
+	return enc.NewDecoder().Reader(br), name, nil
+}
+
+// detect encoding of head of file without BOM
+func detect(head []byte) string {
+	// rune may be cut at the end of head
+	valid := head
+	for i := 0; i < utf8.UTFMax && len(valid) > 0 && !utf8.Valid(valid); i++ {
+		valid = valid[:len(valid)-1]
+	}
+	if utf8.Valid(valid) && bytes.IndexByte(head, 0) < 0 {
+		return EncodingUTF8
+	}
+
+	// ascii of utf-16 has zero high bytes
+	var even, odd, low, high int
+	for i, c := range head {
+		switch {
+		case c == 0 && i%2 == 0:
+			even++
+		case c == 0:
+			odd++
+		case c >= 0xE0:
+			high++
+		case c >= 0xC0:
+			low++
+		}
+	}
+	if odd > len(head)/4 && odd > even {
+		return EncodingUTF16LE
+	}
+	if even > len(head)/4 {
+		return EncodingUTF16BE
+	}
+	// lower case letters are more often, they are 0xE0-0xFF in cp1251 and 0xC0-0xDF in koi8-r
+	if low > high {
+		return EncodingKOI8R
+	}
+	return EncodingCP1251
+}
@@ -0,0 +1,59 @@
+package workers
+
+import (
+	"bytes"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+	"io"
+	"testing"
+)
+
+func TestDecode(t *testing.T) {
+	text := "5\tG-044325\t01749246-9617-585e-9e19-157ccad61ee2\tРазморозка\tожидание\n"
+	encode := func(e encoding.Encoding, bom ...byte) string {
+		s, err := e.NewEncoder().String(text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(bom) + s
+	}
+	tests := []struct {
+		name     string
+		data     string
+		encoding string
+		want     string
+		wantErr  bool
+	}{
+		{name: "OK#1", data: text, want: EncodingUTF8},
+		{name: "OK#2", data: "\xEF\xBB\xBF" + text, encoding: EncodingCP1251, want: EncodingUTF8},
+		{name: "OK#3", data: encode(charmap.Windows1251), want: EncodingCP1251},
+		{name: "OK#4", data: encode(charmap.KOI8R), encoding: EncodingAuto, want: EncodingKOI8R},
+		{name: "OK#5", data: encode(unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM), 0xFF, 0xFE), want: EncodingUTF16LE},
+		{name: "OK#6", data: encode(unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM), 0xFE, 0xFF), want: EncodingUTF16BE},
+		{name: "OK#7", data: encode(unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM)), want: EncodingUTF16LE},
+		{name: "OK#8", data: encode(charmap.KOI8R), encoding: EncodingKOI8R, want: EncodingKOI8R},
+		{name: "BAD#1", data: text, encoding: "latin1", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, got, err := Decode(bytes.NewReader([]byte(tt.data)), tt.encoding)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got %v, want err %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got != tt.want {
+				t.Errorf("got encoding %q, want %q", got, tt.want)
+			}
+			data, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != text {
+				t.Errorf("got %q, want %q", data, text)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package workers
+
+import (
+	"fmt"
+	"goTSVParser/config"
+	"goTSVParser/internal/shema"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Filter keep rows matching all rules of config
+type Filter struct {
+	rules []rule
+}
+
+type rule struct {
+	name  string
+	field int
+	match func(string) bool
+}
+
+// NewFilter compile rules on columns, unknown fields and operators are errors
+func NewFilter(columns []config.Column, rules []config.FilterRule) (*Filter, error) {
+	fields := make(map[string]int)
+	for i, c := range columns {
+		fields[c.Name] = i
+	}
+	f := &Filter{}
+	for i, r := range rules {
+		field, ok := fields[r.Field]
+		if !ok {
+			return nil, fmt.Errorf("filter %d: unknown field %q", i, r.Field)
+		}
+		match, err := matcher(r.Op, r.Value)
+		if err != nil {
+			return nil, fmt.Errorf("filter %d: %w", i, err)
+		}
+		name := r.Name
+		if name == "" {
+			name = strings.TrimSpace(r.Field + " " + r.Op + " " + r.Value)
+		}
+		f.rules = append(f.rules, rule{name: name, field: field, match: match})
+	}
+	return f, nil
+}
+
+func matcher(op, value string) (func(string) bool, error) {
+	switch op {
+	case "eq":
+		return func(s string) bool { return s == value }, nil
+	case "ne":
+		return func(s string) bool { return s != value }, nil
+	case "contains":
+		return func(s string) bool { return strings.Contains(s, value) }, nil
+	case "prefix":
+		return func(s string) bool { return strings.HasPrefix(s, value) }, nil
+	case "suffix":
+		return func(s string) bool { return strings.HasSuffix(s, value) }, nil
+	case "regex", "not_regex":
+		re, err := regexp.Compile(value)
+		if err != nil {
+			return nil, fmt.Errorf("bad regex: %w", err)
+		}
+		want := op == "regex"
+		return func(s string) bool { return re.MatchString(s) == want }, nil
+	case "min_len", "max_len":
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("bad length %q of %s", value, op)
+		}
+		if op == "min_len" {
+			return func(s string) bool { return utf8.RuneCountInString(s) >= n }, nil
+		}
+		return func(s string) bool { return utf8.RuneCountInString(s) <= n }, nil
+	case "required":
+		return func(s string) bool { return s != "" }, nil
+	default:
+		return nil, fmt.Errorf("unknown operator %q", op)
+	}
+}
+
+// Drop return name of first rule which row doesn't match, empty when row is kept
+func (f *Filter) Drop(row shema.Record) string {
+	if f == nil {
+		return ""
+	}
+	for _, r := range f.rules {
+		if !r.match(row.Values[r.field]) {
+			return r.name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,43 @@
+package workers
+
+import (
+	"goTSVParser/config"
+	"goTSVParser/internal/shema"
+	"testing"
+)
+
+func TestFilter_Drop(t *testing.T) {
+	row := shema.Tsv{UnitGUID: "01749246-9617-585e-9e19-157ccad61ee2", Level: "100", MessageText: "Разморозка", Area: "LOCAL"}.Record()
+	columns := OccurrenceSchema(config.Parser{}).Columns
+	tests := []struct {
+		name    string
+		rules   []config.FilterRule
+		want    string
+		wantErr bool
+	}{
+		{name: "OK#1", rules: config.DefaultFilters},
+		{name: "OK#2", rules: []config.FilterRule{{Field: "area", Op: "eq", Value: "REMOTE"}}, want: "area eq REMOTE"},
+		{name: "OK#3", rules: []config.FilterRule{{Field: "level", Op: "regex", Value: `^\d+$`}, {Field: "context", Op: "required"}},
+			want: "context required"},
+		{name: "OK#4", rules: []config.FilterRule{{Name: "long_text", Field: "text", Op: "max_len", Value: "9"}}, want: "long_text"},
+		{name: "OK#5", rules: []config.FilterRule{{Field: "msg_id", Op: "not_regex", Value: "^test_"}, {Field: "unit_guid", Op: "prefix", Value: "0174"}}},
+		{name: "BAD#1", rules: []config.FilterRule{{Field: "guid", Op: "required"}}, wantErr: true},
+		{name: "BAD#2", rules: []config.FilterRule{{Field: "level", Op: "gt", Value: "1"}}, wantErr: true},
+		{name: "BAD#3", rules: []config.FilterRule{{Field: "level", Op: "regex", Value: "("}}, wantErr: true},
+		{name: "BAD#4", rules: []config.FilterRule{{Field: "level", Op: "min_len", Value: "ten"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := NewFilter(columns, tt.rules)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got %v, want err %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got := f.Drop(row); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
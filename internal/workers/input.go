@@ -0,0 +1,212 @@
+package workers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/xuri/excelize/v2"
+	"io"
+	"strings"
+)
+
+const (
+	InputTSV   = "tsv"
+	InputCSV   = "csv"
+	InputXLSX  = "xlsx"
+	InputJSONL = "jsonl"
+)
+
+// Input is open input file
+type Input struct {
+	RowReader
+	Format   string
+	Encoding string // source encoding of text formats, text is read as utf-8
+	file     io.Closer
+}
+
+// OpenInput open logical file of format, it is decompressed and read from archive when needed.
+// Text formats are converted to utf-8 from encoding of schema
+func OpenInput(path, format string, schema *Schema) (*Input, error) {
+	file, err := openFile(path)
+	if err != nil {
+		return nil, err
+	}
+	in := &Input{Format: format, file: file}
+	var r io.Reader = file
+	// xlsx is zip of utf-8 xml
+	if format != InputXLSX {
+		r, in.Encoding, err = Decode(file, schema.Encoding)
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+	in.RowReader, err = NewRowReader(format, r, schema.Names())
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return in, nil
+}
+
+// Close reader of format when it holds resources, e.g. xlsx workbook, and then file
+func (in *Input) Close() error {
+	var err error
+	if c, ok := in.RowReader.(io.Closer); ok {
+		err = c.Close()
+	}
+	return errors.Join(err, in.file.Close())
+}
+
+// RowReader read rows of input file as values in order of columns, io.EOF after last row
+type RowReader interface {
+	Read() ([]string, error)
+}
+
+// NewRowReader of input format, columns are names of schema columns
+func NewRowReader(format string, r io.Reader, columns []string) (RowReader, error) {
+	switch format {
+	case InputTSV:
+		return delimited(r, '\t'), nil
+	case InputCSV:
+		br := bufio.NewReader(r)
+		return delimited(br, sniffDelimiter(br)), nil
+	case InputXLSX:
+		return newXLSXReader(r, len(columns))
+	case InputJSONL:
+		return newJSONLReader(r, columns), nil
+	default:
+		return nil, fmt.Errorf("unknown input format %q", format)
+	}
+}
+
+func delimited(r io.Reader, comma rune) *csv.Reader {
+	reader := csv.NewReader(r)
+	reader.Comma = comma
+	// number of columns is checked by schema
+	reader.FieldsPerRecord = -1
+	return reader
+}
+
+// delimiters which are sniffed, comma is default
+var delimiters = []rune{',', ';', '\t', '|'}
+
+// sniffDelimiter return delimiter met most often out of quotes in first line
+func sniffDelimiter(r *bufio.Reader) rune {
+	const size = 64 * 1024
+	head, _ := r.Peek(size)
+	if i := bytes.IndexByte(head, '\n'); i >= 0 {
+		head = head[:i]
+	}
+	counts := make(map[rune]int)
+	quoted := false
+	for _, c := range string(head) {
+		if c == '"' {
+			quoted = !quoted
+		} else if !quoted {
+			counts[c]++
+		}
+	}
+	best := delimiters[0]
+	for _, d := range delimiters {
+		if counts[d] > counts[best] {
+			best = d
+		}
+	}
+	return best
+}
+
+// xlsxReader read rows of first sheet of workbook
+type xlsxReader struct {
+	file    *excelize.File
+	rows    *excelize.Rows
+	columns int
+	closed  bool
+}
+
+func newXLSXReader(r io.Reader, columns int) (*xlsxReader, error) {
+	f, err := excelize.OpenReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open xlsx: %w", err)
+	}
+	rows, err := f.Rows(f.GetSheetName(0))
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to read xlsx: %w", err)
+	}
+	return &xlsxReader{file: f, rows: rows, columns: columns}, nil
+}
+
+func (x *xlsxReader) Read() ([]string, error) {
+	if x.closed {
+		return nil, io.EOF
+	}
+	if !x.rows.Next() {
+		if err := x.rows.Error(); err != nil {
+			return nil, err
+		}
+		if err := x.Close(); err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	}
+	row, err := x.rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	// empty cells at the end of row are not returned
+	for len(row) < x.columns {
+		row = append(row, "")
+	}
+	return row, nil
+}
+
+// Close rows and workbook, it is closed after last row or by Input.Close when reading is stopped earlier
+func (x *xlsxReader) Close() error {
+	if x.closed {
+		return nil
+	}
+	x.closed = true
+	return errors.Join(x.rows.Close(), x.file.Close())
+}
+
+// jsonlReader read objects of json lines, values are taken by names of columns
+type jsonlReader struct {
+	decoder *json.Decoder
+	columns []string
+}
+
+func newJSONLReader(r io.Reader, columns []string) *jsonlReader {
+	return &jsonlReader{decoder: json.NewDecoder(r), columns: columns}
+}
+
+func (j *jsonlReader) Read() ([]string, error) {
+	var object map[string]json.RawMessage
+	if err := j.decoder.Decode(&object); err != nil {
+		if err == io.EOF {
+			return nil, err
+		}
+		return nil, fmt.Errorf("bad json line: %w", err)
+	}
+	row := make([]string, len(j.columns))
+	for i, name := range j.columns {
+		row[i] = jsonValue(object[name])
+	}
+	return row, nil
+}
+
+// jsonValue is string as is, null as empty and other values as json, e.g. 5 or true
+func jsonValue(raw json.RawMessage) string {
+	value := strings.TrimSpace(string(raw))
+	if value == "" || value == "null" {
+		return ""
+	}
+	var s string
+	if value[0] == '"' && json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+	return value
+}
@@ -0,0 +1,106 @@
+package workers
+
+import (
+	"bytes"
+	"github.com/xuri/excelize/v2"
+	"goTSVParser/config"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func xlsxOf(t *testing.T, rows ...[]interface{}) string {
+	f := excelize.NewFile()
+	defer f.Close()
+	for i, row := range rows {
+		cell, _ := excelize.CoordinatesToCellName(1, i+1)
+		if err := f.SetSheetRow(f.GetSheetName(0), cell, &row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var buf bytes.Buffer
+	if err := f.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestNewRowReader(t *testing.T) {
+	columns := []string{"device", "code", "message"}
+	tests := []struct {
+		name    string
+		format  string
+		data    string
+		want    [][]string
+		wantErr bool
+	}{
+		{name: "OK#1", format: InputTSV, data: "a\t1\tx,y\nb\t2\n",
+			want: [][]string{{"a", "1", "x,y"}, {"b", "2"}}},
+		{name: "OK#2", format: InputCSV, data: "a;1;\"x;y\"\nb;2;z\n",
+			want: [][]string{{"a", "1", "x;y"}, {"b", "2", "z"}}},
+		{name: "OK#3", format: InputCSV, data: "a,\"1;2;3\",x\n",
+			want: [][]string{{"a", "1;2;3", "x"}}},
+		{name: "OK#4", format: InputJSONL, data: "{\"device\": \"a\", \"code\": 7, \"message\": \"Разморозка\"}\n\n{\"device\": \"b\", \"code\": null, \"extra\": true}\n",
+			want: [][]string{{"a", "7", "Разморозка"}, {"b", "", ""}}},
+		{name: "OK#5", format: InputXLSX, data: xlsxOf(t, []interface{}{"device", "code", "message"}, []interface{}{"a", 1}),
+			want: [][]string{{"device", "code", "message"}, {"a", "1", ""}}},
+		{name: "BAD#1", format: InputJSONL, data: "{\"device\": \"a\"}\n[1, 2]\n", want: [][]string{{"a", "", ""}}, wantErr: true},
+		{name: "BAD#2", format: InputXLSX, data: "a\tb\n", wantErr: true},
+		{name: "BAD#3", format: "xml", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got [][]string
+			r, err := NewRowReader(tt.format, strings.NewReader(tt.data), columns)
+			for err == nil {
+				var row []string
+				row, err = r.Read()
+				if err == nil {
+					got = append(got, row)
+				}
+			}
+			if (err != io.EOF) != tt.wantErr {
+				t.Fatalf("got %v, want err %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInput_CloseXLSX(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a.xlsx")
+	data := xlsxOf(t, []interface{}{"a", 1}, []interface{}{"b", 2})
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	schema, err := NewSchema(config.Schema{Name: "alarms", Key: "device", Columns: []config.Column{{Name: "device"}, {Name: "code"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	in, err := OpenInput(path, InputXLSX, schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := in.Read(); err != nil {
+		t.Fatal(err)
+	}
+	// reading is stopped before the last row
+	if err := in.Close(); err != nil {
+		t.Fatalf("got %v", err)
+	}
+	x := in.RowReader.(*xlsxReader)
+	if !x.closed {
+		t.Error("workbook is not closed")
+	}
+	if err := x.Close(); err != nil {
+		t.Errorf("second close: got %v", err)
+	}
+	if _, err := in.Read(); err != io.EOF {
+		t.Errorf("read after close: got %v, want %v", err, io.EOF)
+	}
+}
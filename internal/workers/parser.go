@@ -1,92 +1,205 @@
 package workers
 
 import (
-	"encoding/csv"
+	"context"
+	"fmt"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
 	"goTSVParser/config"
 	"goTSVParser/internal/constants"
+	"goTSVParser/internal/logging"
+	"goTSVParser/internal/metrics"
 	"goTSVParser/internal/shema"
+	"goTSVParser/internal/tracing"
 	"io"
-	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 )
 
 type Parser struct {
-	dirFrom string
+	schemas []*Schema // schemas of config in their order, occurrence schema is last
+	formats map[string]string
+	logger  *zap.Logger
+	rows    *zap.Logger // sampled logger of per-row messages
 }
 
-func NewParser(cfg config.Config) *Parser {
-	return &Parser{dirFrom: cfg.DirectoryFrom}
+func NewParser(cfg config.Config, logger *zap.Logger) (*Parser, error) {
+	s := &Parser{formats: cfg.Parser.Formats, logger: logger, rows: logging.Sampled(logger, cfg.Log)}
+	if s.formats == nil {
+		s.formats = config.DefaultFormats
+	}
+	for _, c := range append(slices.Clone(cfg.Parser.Schemas), OccurrenceSchema(cfg.Parser)) {
+		if c.Encoding == "" {
+			c.Encoding = cfg.Parser.Encoding
+		}
+		schema, err := NewSchema(c)
+		if err != nil {
+			return nil, fmt.Errorf("bad parser schema: %w", err)
+		}
+		s.schemas = append(s.schemas, schema)
+	}
+	return s, nil
+}
+
+// Schemas of config, occurrence schema is last
+func (s *Parser) Schemas() []*Schema {
+	return s.schemas
 }
 
-// ParseFileAsync parse file
-func (s *Parser) ParseFileAsync(fileName string) (<-chan shema.Tsv, <-chan string, <-chan error) {
-	tsvChan := make(chan shema.Tsv)
-	guidChan := make(chan string)
+// FormatOf file by its extension, extension of compression is skipped, e.g. tsv for a.tsv.gz
+func (s *Parser) FormatOf(fileName string) (string, bool) {
+	base, _ := Decompressed(fileName)
+	format, ok := s.formats[strings.ToLower(filepath.Ext(base))]
+	return format, ok
+}
+
+// SchemaOf file, occurrence schema when no other one matches
+func (s *Parser) SchemaOf(fileName string) *Schema {
+	last := len(s.schemas) - 1
+	for _, schema := range s.schemas[:last] {
+		if schema.Matches(fileName) {
+			return schema
+		}
+	}
+	return s.schemas[last]
+}
+
+// errors of so many first invalid fields of file are kept
+const maxRowErrors = 100
+
+// ParseStats of file, it is filled when channels of file are closed
+type ParseStats struct {
+	Rows    int
+	Dropped map[string]int // rows by name of filter rule they don't match
+	Invalid int
+	Errors  []shema.RowError
+}
+
+// ParseFileAsync parse file by schema, keys of rows are sent once. Span of parsing ends when channels are closed
+func (s *Parser) ParseFileAsync(ctx context.Context, fileName string, schema *Schema) (<-chan shema.Record, <-chan string, <-chan error, *ParseStats) {
+	recordChan := make(chan shema.Record)
+	keyChan := make(chan string)
 	errChan := make(chan error)
+	stats := &ParseStats{}
 
+	_, span := tracer.Start(ctx, "parser.Parse", trace.WithAttributes(
+		attribute.String("file", fileName), attribute.String("schema", schema.Name)))
+	rows, dropped, invalid := 0, make(map[string]int), 0
+	var rowErrors []shema.RowError
 	go func() {
-		defer close(tsvChan)
-		defer close(guidChan)
+		defer close(recordChan)
+		defer close(keyChan)
 		defer close(errChan)
+		defer func() {
+			// stats are written before channels are closed, so receiver can read them after
+			stats.Rows, stats.Dropped, stats.Invalid, stats.Errors = rows, dropped, invalid, rowErrors
+			total := 0
+			for _, n := range dropped {
+				total += n
+			}
+			span.SetAttributes(attribute.Int("rows", rows), attribute.Int("dropped", total), attribute.Int("invalid", invalid))
+			span.End()
+		}()
 
-		guidMap := make(map[string]bool)
+		keyMap := make(map[string]bool)
 
-		file, err := os.Open(fileName)
-		if err != nil {
-			errChan <- err
+		if IsArchive(fileName) {
+			// archive is parsed only when it can't be listed
+			_, err := Members(fileName, s.logger)
+			if err == nil {
+				err = fmt.Errorf("%w: archive is not a data file", constants.ErrInvalidTSV)
+			}
+			tracing.Fail(span, err)
+			send(ctx, errChan, err)
 			return
 		}
-
-		if !strings.HasSuffix(file.Name(), ".tsv") {
-			errChan <- constants.ErrNotTSV
+		format, ok := s.FormatOf(fileName)
+		if !ok {
+			tracing.Fail(span, constants.ErrNotTSV)
+			send(ctx, errChan, constants.ErrNotTSV)
 			return
 		}
+		span.SetAttributes(attribute.String("format", format))
 
-		defer file.Close()
-
-		reader := csv.NewReader(file)
-		reader.Comma = '\t'
-		for {
+		reader, err := OpenInput(fileName, format, schema)
+		if err != nil {
+			tracing.Fail(span, err)
+			send(ctx, errChan, err)
+			return
+		}
+		defer reader.Close()
+		span.SetAttributes(attribute.String("encoding", reader.Encoding))
+		for line := 1; ; line++ {
 			str, err := reader.Read()
 			if err != nil {
 				if err == io.EOF {
 					return
 				}
-				errChan <- err
+				tracing.Fail(span, err)
+				send(ctx, errChan, err)
 				return
 			}
 			if str == nil {
 				break
 			}
-			if len(strings.TrimSpace(str[3])) < 10 {
+			if line == 1 && schema.header(str) {
 				continue
 			}
 
-			t := shema.Tsv{
-				Number:       strings.TrimSpace(str[0]),
-				MQTT:         strings.TrimSpace(str[1]),
-				InventoryID:  strings.TrimSpace(str[2]),
-				UnitGUID:     strings.TrimSpace(str[3]),
-				MessageID:    strings.TrimSpace(str[4]),
-				MessageText:  strings.TrimSpace(str[5]),
-				Context:      strings.TrimSpace(str[6]),
-				MessageClass: strings.TrimSpace(str[7]),
-				Level:        strings.TrimSpace(str[8]),
-				Area:         strings.TrimSpace(str[9]),
-				Address:      strings.TrimSpace(str[10]),
-				Block:        strings.TrimSpace(str[11]),
-				Type:         strings.TrimSpace(str[12]),
-				Bit:          strings.TrimSpace(str[13]),
-				InvertBit:    strings.TrimSpace(str[14]),
+			row, err := schema.record(str)
+			if err != nil {
+				err = fmt.Errorf("line %d: %w", line, err)
+				tracing.Fail(span, err)
+				send(ctx, errChan, err)
+				return
+			}
+			if rule := schema.filter.Drop(row); rule != "" {
+				dropped[rule]++
+				metrics.RowsDropped.WithLabelValues(rule).Inc()
+				// logged for every such row, sampling keeps it bounded
+				s.rows.Debug("row dropped", logging.File(fileName), zap.Int("line", line), zap.String("rule", rule),
+					logging.Stage(metrics.StageParse))
+				continue
 			}
-			tsvChan <- t
+			if errs := schema.validator.Validate(&row); len(errs) > 0 {
+				invalid++
+				for i := range errs {
+					errs[i].Line = line
+					metrics.RowErrors.WithLabelValues(errs[i].Field).Inc()
+					if len(rowErrors) < maxRowErrors {
+						rowErrors = append(rowErrors, errs[i])
+					}
+				}
+				s.rows.Debug("row invalid", logging.File(fileName), zap.Int("line", line), zap.Any("errors", errs),
+					logging.Stage(metrics.StageParse))
+				continue
+			}
+			if !send(ctx, recordChan, row) {
+				return
+			}
+			rows++
+			metrics.RowsParsed.Inc()
 
-			if _, exists := guidMap[t.UnitGUID]; !exists {
-				guidChan <- t.UnitGUID
-				guidMap[t.UnitGUID] = true
+			if _, exists := keyMap[row.Key]; !exists {
+				if !send(ctx, keyChan, row.Key) {
+					return
+				}
+				keyMap[row.Key] = true
 			}
 		}
 	}()
 
-	return tsvChan, guidChan, errChan
+	return recordChan, keyChan, errChan, stats
+}
+
+// send v unless ctx is done, receiver cancels ctx when it stops reading
+func send[T any](ctx context.Context, ch chan<- T, v T) bool {
+	select {
+	case ch <- v:
+		return true
+	case <-ctx.Done():
+		return false
+	}
 }
@@ -1,8 +1,10 @@
 package workers
 
 import (
+	"context"
 	"encoding/csv"
 	"errors"
+	"go.uber.org/zap"
 	"goTSVParser/config"
 	"goTSVParser/internal/constants"
 	"goTSVParser/internal/shema"
@@ -11,6 +13,7 @@ import (
 	"reflect"
 	"sync"
 	"testing"
+	"time"
 )
 
 func TestService_ParseFile(t *testing.T) {
@@ -114,12 +117,17 @@ func TestService_ParseFile(t *testing.T) {
 				t.Errorf("not writing temp file: %v", err)
 				return
 			}
-			s := &Parser{
-				dirFrom: config.Config{DirectoryFrom: tempDir}.DirectoryFrom,
+			s, err := NewParser(config.Config{DirectoryFrom: tempDir}, zap.NewNop())
+			if err != nil {
+				t.Fatal(err)
 			}
-			tsvChan, guidChan, errChan := s.ParseFileAsync(filepath.Join(tempDir, tt.args.file))
+			path := filepath.Join(tempDir, tt.args.file)
+			tsvChan, guidChan, errChan, _ := s.ParseFileAsync(context.Background(), path, s.SchemaOf(path))
 
-			var gotTsv []shema.Tsv
+			var gotTsv, wantTsv []shema.Record
+			for _, t := range tt.wantTsv {
+				wantTsv = append(wantTsv, t.Record())
+			}
 			var gotGuids []string
 			var gotErr error
 			var wg sync.WaitGroup
@@ -155,8 +163,8 @@ func TestService_ParseFile(t *testing.T) {
 				t.Errorf("ParseFileAsync() error = %v, wantErr %v", gotErr, tt.wantErr)
 				return
 			}
-			if !reflect.DeepEqual(gotTsv, tt.wantTsv) {
-				t.Errorf("ParseFileAsync() got = %v, want %v", gotTsv, tt.wantTsv)
+			if !reflect.DeepEqual(gotTsv, wantTsv) {
+				t.Errorf("ParseFileAsync() got = %v, want %v", gotTsv, wantTsv)
 			}
 			if !reflect.DeepEqual(gotGuids, tt.wantGuids) {
 				t.Errorf("ParseFileAsync() got1 = %v, want %v", gotGuids, tt.wantGuids)
@@ -165,6 +173,43 @@ func TestService_ParseFile(t *testing.T) {
 	}
 }
 
+func TestParser_ParseFileAsyncCancel(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.tsv")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = writeDataToFile(file, []shema.Tsv{
+		{UnitGUID: "01749246-9617-585e-9e19-157ccad61ee2"},
+		{UnitGUID: "01749246-9617-585e-9e19-157ccad61ee3"},
+	})
+	file.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewParser(config.Config{DirectoryFrom: dir}, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	// keys are not read, parser must stop on cancel instead of waiting for receiver
+	rowChan, _, _, _ := s.ParseFileAsync(ctx, path, s.SchemaOf(path))
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case _, ok := <-rowChan:
+			if !ok {
+				return
+			}
+		case <-timeout:
+			t.Fatal("parser is not stopped")
+		}
+	}
+}
+
 func createTempDir(dir string, t *testing.T) (string, error) {
 	tempDir, err := os.MkdirTemp(".", dir)
 	if err != nil {
@@ -0,0 +1,113 @@
+package workers
+
+import (
+	"fmt"
+	"goTSVParser/config"
+	"goTSVParser/internal/shema"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+// Schema is layout of file compiled from config
+type Schema struct {
+	config.Schema
+	key       int
+	filter    *Filter
+	validator *Validator
+}
+
+// OccurrenceName is name of schema of shema.Tsv
+const OccurrenceName = "occurrence"
+
+// OccurrenceSchema is layout of shema.Tsv, rows are saved into occurrence table and grouped by unit guid.
+// Filters and validation of parser config are applied to it
+func OccurrenceSchema(cfg config.Parser) config.Schema {
+	v := cfg.Validation
+	ranges := map[string]config.Range{"n": v.Number, "level": v.Level, "bit": v.Bit}
+	enums := map[string][]string{"class": v.Classes, "area": v.Areas}
+
+	s := config.Schema{Name: OccurrenceName, Table: "occurrence", Key: "unit_guid", Filters: cfg.Filters, Encoding: cfg.Encoding}
+	t := reflect.TypeOf(shema.Tsv{})
+	for i := 0; i < t.NumField(); i++ {
+		name := t.Field(i).Tag.Get("tsv")
+		if name == "" {
+			continue
+		}
+		field := t.Field(i).Name
+		s.Columns = append(s.Columns, config.Column{
+			Name:   name,
+			Column: strings.ToLower(field),
+			Type:   shema.TsvKind(field),
+			Min:    ranges[name].Min,
+			Max:    ranges[name].Max,
+			Values: enums[name],
+		})
+	}
+	return s
+}
+
+// NewSchema compile filters of schema, schema itself is validated with config
+func NewSchema(s config.Schema) (*Schema, error) {
+	filter, err := NewFilter(s.Columns, s.Filters)
+	if err != nil {
+		return nil, fmt.Errorf("schema %s: %w", s.Name, err)
+	}
+	key := -1
+	for i, c := range s.Columns {
+		if c.Name == s.Key {
+			key = i
+		}
+	}
+	if key < 0 {
+		return nil, fmt.Errorf("schema %s: key %q is not a column", s.Name, s.Key)
+	}
+	return &Schema{Schema: s, key: key, filter: filter, validator: NewValidator(s.Columns)}, nil
+}
+
+// Names of columns in order of file
+func (s *Schema) Names() []string {
+	names := make([]string, len(s.Columns))
+	for i, c := range s.Columns {
+		names[i] = c.Name
+	}
+	return names
+}
+
+// Matches tell if file has layout of schema. Pattern is matched against file name,
+// or against so many last directories and file name as it has, e.g. alarms/*.tsv
+func (s *Schema) Matches(file string) bool {
+	parts := strings.Split(filepath.ToSlash(file), "/")
+	n := strings.Count(s.Match, "/") + 1
+	if n > len(parts) {
+		return false
+	}
+	ok, _ := filepath.Match(s.Match, strings.Join(parts[len(parts)-n:], "/"))
+	return ok
+}
+
+// record of row of file, extra columns are ignored
+func (s *Schema) record(row []string) (shema.Record, error) {
+	if len(row) < len(s.Columns) {
+		return shema.Record{}, fmt.Errorf("got %d columns, want %d of schema %s", len(row), len(s.Columns), s.Name)
+	}
+	r := shema.Record{Values: make([]string, len(s.Columns))}
+	for i := range s.Columns {
+		r.Values[i] = strings.TrimSpace(row[i])
+	}
+	r.Key = r.Values[s.key]
+	return r, nil
+}
+
+// header tell if row is names of columns, e.g. first row of csv or xlsx export
+func (s *Schema) header(row []string) bool {
+	if len(row) < len(s.Columns) {
+		return false
+	}
+	for i, c := range s.Columns {
+		if !strings.EqualFold(strings.TrimSpace(row[i]), c.Name) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,105 @@
+package workers
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"goTSVParser/config"
+	"goTSVParser/internal/shema"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+var alarms = config.Schema{
+	Name:  "alarms",
+	Match: "alarms/*.tsv",
+	Table: "alarms",
+	Key:   "device",
+	Columns: []config.Column{
+		{Name: "device"},
+		{Name: "code", Type: "int"},
+		{Name: "message", Type: "text"},
+	},
+	Filters: []config.FilterRule{{Name: "no_device", Field: "device", Op: "required"}},
+}
+
+func TestParser_SchemaOf(t *testing.T) {
+	p, err := NewParser(config.Config{Parser: config.Parser{Schemas: []config.Schema{alarms}}}, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		file string
+		want string
+	}{
+		{name: "OK#1", file: "/data/from/alarms/a.tsv", want: "alarms"},
+		{name: "OK#2", file: "/data/from/a.tsv", want: "occurrence"},
+		{name: "OK#3", file: "/data/from/alarms/old/a.tsv", want: "occurrence"},
+		{name: "OK#4", file: "a.tsv", want: "occurrence"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := p.SchemaOf(tt.file).Name; got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParser_ParseFileAsync_Schema(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "a.tsv")
+	data := "Device\tcode\tmessage\ndev-1\t007\tdoor open\textra\n\t1\tno device\n dev-2 \t\tok\n"
+	if err := os.WriteFile(file, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+	schema, err := NewSchema(alarms)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := NewParser(config.Config{}, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	rowChan, keyChan, errChan, stats := p.ParseFileAsync(context.Background(), file, schema)
+
+	var rows []shema.Record
+	var keys []string
+	for rowChan != nil || keyChan != nil || errChan != nil {
+		select {
+		case row, ok := <-rowChan:
+			if !ok {
+				rowChan = nil
+				continue
+			}
+			rows = append(rows, row)
+		case key, ok := <-keyChan:
+			if !ok {
+				keyChan = nil
+				continue
+			}
+			keys = append(keys, key)
+		case err, ok := <-errChan:
+			if !ok {
+				errChan = nil
+				continue
+			}
+			t.Fatal(err)
+		}
+	}
+
+	want := []shema.Record{
+		{Key: "dev-1", Values: []string{"dev-1", "7", "door open"}},
+		{Key: "dev-2", Values: []string{"dev-2", "", "ok"}},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("got %v, want %v", rows, want)
+	}
+	if !reflect.DeepEqual(keys, []string{"dev-1", "dev-2"}) {
+		t.Errorf("got keys %v", keys)
+	}
+	if stats.Rows != 2 || stats.Dropped["no_device"] != 1 {
+		t.Errorf("got stats %+v", stats)
+	}
+}
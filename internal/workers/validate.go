@@ -0,0 +1,102 @@
+package workers
+
+import (
+	"fmt"
+	"goTSVParser/config"
+	"goTSVParser/internal/shema"
+	"regexp"
+	"strconv"
+	"unicode/utf8"
+)
+
+// maxLen of varchar columns
+const maxLen = 255
+
+var guidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// Validator check rows against types of db columns and ranges and enumerations of schema columns
+type Validator struct {
+	columns []config.Column
+	enums   []map[string]bool
+}
+
+func NewValidator(columns []config.Column) *Validator {
+	v := &Validator{columns: columns, enums: make([]map[string]bool, len(columns))}
+	for i, c := range columns {
+		if len(c.Values) == 0 {
+			continue
+		}
+		v.enums[i] = make(map[string]bool)
+		for _, value := range c.Values {
+			v.enums[i][value] = true
+		}
+	}
+	return v
+}
+
+// Validate check values of row and normalize typed ones, e.g. level 007 becomes 7 and invert bit 1 becomes true.
+// Errors have no line, it is set by caller
+func (v *Validator) Validate(row *shema.Record) []shema.RowError {
+	if v == nil {
+		return nil
+	}
+	var errs []shema.RowError
+	for i, c := range v.columns {
+		value, reason := v.check(i, row.Values[i])
+		if reason != "" {
+			errs = append(errs, shema.RowError{Field: c.Name, Value: truncate(row.Values[i]), Reason: reason})
+			continue
+		}
+		row.Values[i] = value
+	}
+	return errs
+}
+
+// check return normalized value or reason why it is invalid
+func (v *Validator) check(i int, value string) (string, string) {
+	if enum := v.enums[i]; enum != nil && !enum[value] {
+		return "", "is not allowed"
+	}
+	c := v.columns[i]
+	switch c.Type {
+	case shema.KindGUID:
+		if !guidPattern.MatchString(value) {
+			return "", "is not a guid"
+		}
+	case shema.KindInt:
+		if value == "" {
+			return value, ""
+		}
+		n, err := strconv.ParseInt(value, 10, 32)
+		if err != nil {
+			return "", "is not an integer"
+		}
+		if c.Min != nil && int(n) < *c.Min || c.Max != nil && int(n) > *c.Max {
+			return "", "is out of range"
+		}
+		return strconv.FormatInt(n, 10), ""
+	case shema.KindBool:
+		if value == "" {
+			return value, ""
+		}
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return "", "is not a boolean"
+		}
+		return strconv.FormatBool(b), ""
+	case shema.KindString, "":
+		if utf8.RuneCountInString(value) > maxLen {
+			return "", fmt.Sprintf("is longer than %d characters", maxLen)
+		}
+	}
+	return value, ""
+}
+
+// truncate long values in errors, they are kept in job
+func truncate(value string) string {
+	const max = 64
+	if utf8.RuneCountInString(value) <= max {
+		return value
+	}
+	return string([]rune(value)[:max]) + "…"
+}
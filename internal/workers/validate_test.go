@@ -0,0 +1,63 @@
+package workers
+
+import (
+	"goTSVParser/config"
+	"goTSVParser/internal/shema"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidator_Validate(t *testing.T) {
+	guid := "01749246-9617-585e-9e19-157ccad61ee2"
+	levelMax, bitMax := 100, 31
+	cfg := config.Validation{
+		Level:   config.Range{Max: &levelMax},
+		Bit:     config.Range{Max: &bitMax},
+		Classes: []string{"waiting", "working"},
+	}
+	tests := []struct {
+		name       string
+		row        shema.Tsv
+		wantRow    shema.Tsv
+		wantFields []string
+	}{
+		{
+			name:    "OK#1",
+			row:     shema.Tsv{UnitGUID: guid, MessageClass: "waiting"},
+			wantRow: shema.Tsv{UnitGUID: guid, MessageClass: "waiting"},
+		},
+		{
+			name:    "OK#2",
+			row:     shema.Tsv{Number: "05", UnitGUID: guid, MessageClass: "working", Level: "100", Bit: "0", InvertBit: "1", Area: "LOCAL"},
+			wantRow: shema.Tsv{Number: "5", UnitGUID: guid, MessageClass: "working", Level: "100", Bit: "0", InvertBit: "true", Area: "LOCAL"},
+		},
+		{
+			name:       "BAD#1",
+			row:        shema.Tsv{UnitGUID: "01749246-9617", MessageClass: "alarm", Level: "abc", Bit: "32", InvertBit: "yes"},
+			wantFields: []string{"unit_guid", "class", "level", "bit", "invert_bit"},
+		},
+		{
+			name:       "BAD#2",
+			row:        shema.Tsv{Number: "99999999999", UnitGUID: guid, MessageClass: "waiting", Address: strings.Repeat("a", 300)},
+			wantFields: []string{"n", "addr"},
+		},
+	}
+	v := NewValidator(OccurrenceSchema(config.Parser{Validation: cfg}).Columns)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			row := tt.row.Record()
+			errs := v.Validate(&row)
+			var fields []string
+			for _, e := range errs {
+				fields = append(fields, e.Field)
+			}
+			if !reflect.DeepEqual(fields, tt.wantFields) {
+				t.Fatalf("got errors %v, want of fields %v", errs, tt.wantFields)
+			}
+			if len(errs) == 0 && !reflect.DeepEqual(row, tt.wantRow.Record()) {
+				t.Errorf("got %+v, want %+v", row, tt.wantRow)
+			}
+		})
+	}
+}
@@ -2,17 +2,36 @@ package workers
 
 import (
 	"context"
-	"fmt"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
 	"goTSVParser/config"
+	"goTSVParser/internal/logging"
+	"goTSVParser/internal/metrics"
 	"goTSVParser/internal/shema"
+	"goTSVParser/internal/tracing"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 )
 
-func NewWatcher(c config.Config) *Watcher {
-	return &Watcher{timer: c.RefreshInterval, fromDir: c.DirectoryFrom, files: make(map[string]struct{})}
+var tracer = otel.Tracer("goTSVParser/internal/workers")
+
+// Found is file found by watcher, its span is root of file trace and is ended by receiver
+type Found struct {
+	Path string
+	Span trace.Span
+}
+
+// watcher is stalled when it has nothing to send and didn't scan for so many intervals
+const stalledIntervals = 3
+
+func NewWatcher(c config.Config, logger *zap.Logger) *Watcher {
+	return &Watcher{timer: c.RefreshInterval, fromDir: c.DirectoryFrom, files: make(map[string]struct{}), now: time.Now, logger: logger,
+		reload: make(chan struct{}, 1)}
 }
 
 type Watcher struct {
@@ -20,6 +39,14 @@ type Watcher struct {
 	timer   int
 	fromDir string
 	files   map[string]struct{}
+	logger  *zap.Logger
+	reload  chan struct{}
+
+	now      func() time.Time
+	started  time.Time
+	lastScan time.Time
+	scanErr  error
+	backlog  int
 }
 
 func (w *Watcher) InitCheckedFiles(files []shema.ParsedFiles) {
@@ -28,40 +55,173 @@ func (w *Watcher) InitCheckedFiles(files []shema.ParsedFiles) {
 	}
 }
 
+// Reload change interval and directory of running watcher, next scan is one new interval later
+func (s *Watcher) Reload(c config.Config) {
+	s.mutex.Lock()
+	s.timer, s.fromDir = c.RefreshInterval, c.DirectoryFrom
+	s.mutex.Unlock()
+	select {
+	case s.reload <- struct{}{}:
+	default:
+	}
+}
+
+func (s *Watcher) interval() time.Duration {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return time.Duration(s.timer) * time.Second
+}
+
+func (s *Watcher) dir() string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.fromDir
+}
+
 // Scan main scan directory
-func (s *Watcher) Scan(ctx context.Context, out chan string) {
+func (s *Watcher) Scan(ctx context.Context, out chan Found) {
+	s.mutex.Lock()
+	s.started = s.now()
+	s.mutex.Unlock()
+
 	go func() {
-		timer := time.NewTicker(time.Duration(s.timer) * time.Second)
+		timer := time.NewTicker(s.interval())
 		defer timer.Stop()
 
 		for {
 			select {
 			case <-ctx.Done():
 				return
+			case <-s.reload:
+				timer.Reset(s.interval())
 			case <-timer.C:
-				err := filepath.Walk(s.fromDir, func(path string, info os.FileInfo, err error) error {
-					if err != nil {
-						return err
-					}
-					if !info.IsDir() {
+				dir := s.dir()
+				scanCtx, scanSpan := tracer.Start(ctx, "watcher.Scan", trace.WithNewRoot(),
+					trace.WithAttributes(attribute.String("dir", dir)))
+				files, archives, err := s.newFiles(dir)
+				scanSpan.SetAttributes(attribute.Int("files", len(files)))
+				if err != nil {
+					tracing.Fail(scanSpan, err)
+				}
+				scanSpan.End()
+				foundAt := s.now()
+
+				s.mutex.Lock()
+				s.scanErr = err
+				if err == nil {
+					s.lastScan = s.now()
+				}
+				s.backlog = len(files)
+				s.mutex.Unlock()
+				if err != nil {
+					s.logger.Error("failed to scan directory", zap.String("dir", dir), zap.Error(err))
+				}
+
+				for _, path := range files {
+					// file trace starts at discovery, so time in backlog is visible
+					_, span := tracer.Start(context.Background(), "file", trace.WithNewRoot(), trace.WithTimestamp(foundAt),
+						trace.WithLinks(trace.LinkFromContext(scanCtx)), trace.WithAttributes(attribute.String("file", path)))
+					select {
+					case out <- Found{Path: path, Span: span}:
+						metrics.FilesDiscovered.Inc()
+						s.logger.Debug("file found", logging.File(path))
 						s.mutex.Lock()
-						_, ok := s.files[path]
-						if ok {
-							s.mutex.Unlock()
-							return nil
-						} else {
-							s.files[path] = struct{}{}
-							s.mutex.Unlock()
-						}
-						out <- path
+						s.files[path] = struct{}{}
+						s.backlog--
+						s.mutex.Unlock()
+					case <-ctx.Done():
+						span.End()
+						return
 					}
-					return nil
-				})
-				if err != nil {
-					fmt.Errorf("error reading %w", err)
-					return
 				}
+				// archives are not listed again when all their members are sent
+				s.mutex.Lock()
+				for _, path := range archives {
+					s.files[path] = struct{}{}
+				}
+				s.mutex.Unlock()
 			}
 		}
 	}()
 }
+
+// newFiles list files of directory which are not sent yet, members of archives are listed instead of archives.
+// Archive which can't be listed is sent as is, so it fails as file
+func (s *Watcher) newFiles(dir string) ([]string, []string, error) {
+	var files, archives []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			// file can be renamed or removed between listing and stat
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		// hidden files are still being written, e.g. uploads in progress
+		if strings.HasPrefix(info.Name(), ".") {
+			return nil
+		}
+		if !info.IsDir() {
+			if s.sent(path) {
+				return nil
+			}
+			if !IsArchive(path) {
+				files = append(files, path)
+				return nil
+			}
+			members, err := Members(path, s.logger)
+			if err != nil {
+				s.logger.Warn("failed to list archive", logging.File(path), zap.Error(err))
+				files = append(files, path)
+				return nil
+			}
+			archives = append(archives, path)
+			for _, member := range members {
+				if !s.sent(member) {
+					files = append(files, member)
+				}
+			}
+		}
+		return nil
+	})
+	return files, archives, err
+}
+
+// sent tell if file is sent or checked before
+func (s *Watcher) sent(path string) bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	_, ok := s.files[path]
+	return ok
+}
+
+// Health report last successful scan and files waiting for worker,
+// watcher fails when it isn't started or has nothing to send and didn't scan for a few intervals
+func (s *Watcher) Health() shema.WatcherHealth {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	h := shema.WatcherHealth{Status: shema.HealthOK, Backlog: s.backlog}
+	if !s.lastScan.IsZero() {
+		lastScan := s.lastScan
+		h.LastScan = &lastScan
+	}
+	if s.scanErr != nil {
+		h.Error = s.scanErr.Error()
+	}
+
+	last := s.lastScan
+	if last.IsZero() {
+		last = s.started
+	}
+	switch {
+	case s.started.IsZero():
+		h.Status, h.Error = shema.HealthFail, "not started"
+	case s.backlog == 0 && s.now().Sub(last) > stalledIntervals*time.Duration(s.timer)*time.Second:
+		h.Status = shema.HealthFail
+		if h.Error == "" {
+			h.Error = "no scan since " + last.Format(time.RFC3339)
+		}
+	}
+	return h
+}
@@ -0,0 +1,129 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"go.uber.org/zap"
+	"goTSVParser/config"
+	"goTSVParser/internal/shema"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWatcher_Health(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		started    time.Time
+		lastScan   time.Time
+		scanErr    error
+		backlog    int
+		wantStatus string
+		wantError  string
+	}{
+		{
+			name:       "OK#1",
+			started:    now.Add(-20 * time.Second),
+			wantStatus: shema.HealthOK,
+		},
+		{
+			name:       "OK#2",
+			started:    now.Add(-time.Hour),
+			lastScan:   now.Add(-20 * time.Second),
+			wantStatus: shema.HealthOK,
+		},
+		{
+			name:       "OK#3",
+			started:    now.Add(-time.Hour),
+			lastScan:   now.Add(-time.Hour),
+			backlog:    3,
+			wantStatus: shema.HealthOK,
+		},
+		{
+			name:       "BAD#1",
+			wantStatus: shema.HealthFail,
+			wantError:  "not started",
+		},
+		{
+			name:       "BAD#2",
+			started:    now.Add(-time.Hour),
+			lastScan:   now.Add(-time.Hour),
+			wantStatus: shema.HealthFail,
+			wantError:  "no scan since 2024-05-01T11:00:00Z",
+		},
+		{
+			name:       "BAD#3",
+			started:    now.Add(-time.Hour),
+			lastScan:   now.Add(-time.Hour),
+			scanErr:    errors.New("lstat from: permission denied"),
+			wantStatus: shema.HealthFail,
+			wantError:  "lstat from: permission denied",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := NewWatcher(config.Config{RefreshInterval: 10}, zap.NewNop())
+			w.now = func() time.Time { return now }
+			w.started, w.lastScan, w.scanErr, w.backlog = tt.started, tt.lastScan, tt.scanErr, tt.backlog
+
+			h := w.Health()
+			if h.Status != tt.wantStatus || h.Error != tt.wantError {
+				t.Errorf("got %s %q, want %s %q", h.Status, h.Error, tt.wantStatus, tt.wantError)
+			}
+			if h.Backlog != tt.backlog {
+				t.Errorf("got backlog %d, want %d", h.Backlog, tt.backlog)
+			}
+		})
+	}
+}
+
+func TestWatcher_NewFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"a.tsv": "", "b.tsv": "", ".c.tsv.part": "", "sub/d.tsv": "",
+		"e.zip": zipOf(t, map[string]string{"x.tsv": ""}), "f.tar": tarOf(t, map[string]string{"x.tsv": "", "y.tsv": ""}),
+		"g.zip": "not a zip"})
+
+	w := NewWatcher(config.Config{DirectoryFrom: dir}, zap.NewNop())
+	w.InitCheckedFiles([]shema.ParsedFiles{{File: filepath.Join(dir, "a.tsv")}, {File: filepath.Join(dir, "f.tar!/x.tsv")}})
+	files, archives, err := w.newFiles(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{filepath.Join(dir, "b.tsv"), filepath.Join(dir, "e.zip!/x.tsv"), filepath.Join(dir, "f.tar!/y.tsv"),
+		filepath.Join(dir, "g.zip"), filepath.Join(dir, "sub/d.tsv")}
+	if !reflect.DeepEqual(files, want) {
+		t.Errorf("got %v, want %v", files, want)
+	}
+	wantArchives := []string{filepath.Join(dir, "e.zip"), filepath.Join(dir, "f.tar")}
+	if !reflect.DeepEqual(archives, wantArchives) {
+		t.Errorf("got archives %v, want %v", archives, wantArchives)
+	}
+}
+
+func TestWatcher_Reload(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.tsv")
+	if err := os.WriteFile(path, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	w := NewWatcher(config.Config{RefreshInterval: 3600, DirectoryFrom: t.TempDir()}, zap.NewNop())
+	out := make(chan Found)
+	w.Scan(ctx, out)
+	w.Reload(config.Config{RefreshInterval: 1, DirectoryFrom: dir})
+
+	select {
+	case found := <-out:
+		found.Span.End()
+		if found.Path != path {
+			t.Errorf("got %s, want %s", found.Path, path)
+		}
+	case <-time.After(3 * time.Second):
+		t.Errorf("file of new directory is not found with new interval")
+	}
+}
@@ -1,88 +1,211 @@
 package workers
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"github.com/signintech/gopdf"
+	"github.com/xuri/excelize/v2"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"goTSVParser/config"
+	"goTSVParser/internal/constants"
+	"goTSVParser/internal/metrics"
 	"goTSVParser/internal/shema"
+	"goTSVParser/internal/tracing"
+	htmltemplate "html/template"
+	"io"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
-	"text/template"
+	"sync"
+	"time"
 )
 
+const (
+	FormatPDF  = "pdf"
+	FormatSVG  = "svg"
+	FormatXLSX = "xlsx"
+	FormatHTML = "html"
+)
+
+// Formats of reports
+var Formats = []string{FormatPDF, FormatSVG, FormatXLSX, FormatHTML}
+
+var contentTypes = map[string]string{
+	FormatPDF:  "application/pdf",
+	FormatSVG:  "image/svg+xml",
+	FormatXLSX: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	FormatHTML: "text/html; charset=utf-8",
+}
+
+// ContentType return mime type of report format
+func ContentType(format string) (string, error) {
+	contentType, ok := contentTypes[format]
+	if !ok {
+		return "", constants.ErrUnsupportedFormat
+	}
+	return contentType, nil
+}
+
 type Writer struct {
+	mutex   sync.RWMutex
 	dirTo   string
 	dirFrom string
+	// oldFrom are dir_from before reloads, files found there before reload and their jobs keep their output dirs
+	oldFrom []string
 }
 
 func NewWriter(cfg config.Config) *Writer {
 	return &Writer{dirTo: cfg.DirectoryTo, dirFrom: cfg.DirectoryFrom}
 }
 
-// WritePDF write pdf files
-func (s *Writer) WritePDF(tsv []shema.Tsv, unitGuid []string, filePath string) error {
-	for _, guid := range unitGuid {
-		pdf := gopdf.GoPdf{}
-		pdf.Start(gopdf.Config{PageSize: *gopdf.PageSizeA4})
-		pdf.AddPage()
+// Reload change directories of reports, files being written keep old ones
+func (s *Writer) Reload(cfg config.Config) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if cfg.DirectoryFrom != s.dirFrom && !slices.Contains(s.oldFrom, s.dirFrom) {
+		s.oldFrom = append(s.oldFrom, s.dirFrom)
+	}
+	s.dirTo, s.dirFrom = cfg.DirectoryTo, cfg.DirectoryFrom
+}
+
+// Render write report of rows with given key in given format, labels are names of columns of rows
+func (s *Writer) Render(ctx context.Context, w io.Writer, format string, labels []string, rows []shema.Record, key string) error {
+	if !slices.Contains(Formats, format) {
+		return constants.ErrUnsupportedFormat
+	}
+	defer metrics.Since(metrics.RenderDuration.WithLabelValues(format), time.Now())
+	_, span := tracer.Start(ctx, "writer.Render", trace.WithAttributes(
+		attribute.String("format", format), attribute.String("key", key), attribute.Int("rows", len(rows))))
+	defer span.End()
 
-		defer pdf.Close()
+	var err error
+	switch format {
+	case FormatPDF:
+		err = s.renderPDF(w, labels, rows, key)
+	case FormatSVG:
+		err = s.renderSVG(w, labels, rows, key)
+	case FormatXLSX:
+		err = s.renderXLSX(w, labels, rows, key)
+	case FormatHTML:
+		err = s.renderHTML(w, labels, rows, key)
+	}
+	if err != nil {
+		tracing.Fail(span, err)
+	}
+	return err
+}
 
-		err := pdf.AddTTFFont("LiberationSerif-Regular", "resources/LiberationSerif-Regular.ttf")
-		if err != nil {
-			return fmt.Errorf("can't add font: %w", err)
+// WritePDF write pdf file for every key
+func (s *Writer) WritePDF(ctx context.Context, labels []string, rows []shema.Record, keys []string, filePath string) error {
+	return s.write(ctx, FormatPDF, labels, rows, keys, filePath)
+}
+
+// WriteSVG write svg file for every key
+func (s *Writer) WriteSVG(ctx context.Context, labels []string, rows []shema.Record, keys []string, filePath string) error {
+	return s.write(ctx, FormatSVG, labels, rows, keys, filePath)
+}
+
+// OutputDir return directory where reports of source file are written, reports of archive member are in directory of archive.
+// Path of file is taken relative to current dir_from or the one it was found in before reload
+func (s *Writer) OutputDir(filePath string) string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	filePath = strings.Replace(filePath, MemberSep, "/", 1)
+	for _, root := range append([]string{s.dirFrom}, s.oldFrom...) {
+		if rel, err := filepath.Rel(root, filePath); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return filepath.Join(s.dirTo, filepath.Dir(rel))
 		}
+	}
+	// file out of known dirs is kept under dir_to
+	return filepath.Join(s.dirTo, filepath.Dir(filepath.Clean("/"+filePath)))
+}
+
+// errBadKey is key which is not a plain file name, keys come from rows of files
+var errBadKey = errors.New("bad report key")
+
+// reportFile is path of report of key in dir
+func reportFile(dir, key, format string) (string, error) {
+	if key == "" || strings.Contains(key, "..") || strings.ContainsAny(key, "/\\\x00") {
+		return "", fmt.Errorf("%w %q", errBadKey, key)
+	}
+	return filepath.Join(dir, key+"."+format), nil
+}
 
-		err = pdf.SetFont("LiberationSerif-Regular", "", 14)
+// write report for every key, reports of bad keys are skipped and reported in error
+func (s *Writer) write(ctx context.Context, format string, labels []string, rows []shema.Record, keys []string, filePath string) error {
+	dir := s.OutputDir(filePath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	var badKeys []error
+	for _, key := range keys {
+		resultFile, err := reportFile(dir, key, format)
 		if err != nil {
-			return fmt.Errorf("can't set font: %w", err)
+			badKeys = append(badKeys, err)
+			continue
 		}
-
-		for _, t := range tsv {
-			var resultArray []string
-			if guid == t.UnitGUID {
-				pdf.AddPage()
-
-				resultArray = append(resultArray, "n: "+strings.TrimSpace(t.Number))
-				resultArray = append(resultArray, "mqtt: "+strings.TrimSpace(t.MQTT))
-				resultArray = append(resultArray, "invid: "+strings.TrimSpace(t.InventoryID))
-				resultArray = append(resultArray, "unit_guid: "+strings.TrimSpace(t.UnitGUID))
-				resultArray = append(resultArray, "msg_id: "+strings.TrimSpace(t.MessageID))
-				resultArray = append(resultArray, "text: "+strings.TrimSpace(t.MessageText))
-				resultArray = append(resultArray, "context: "+strings.TrimSpace(t.Context))
-				resultArray = append(resultArray, "class: "+strings.TrimSpace(t.MessageClass))
-				resultArray = append(resultArray, "level: "+strings.TrimSpace(t.Level))
-				resultArray = append(resultArray, "area: "+strings.TrimSpace(t.Area))
-				resultArray = append(resultArray, "addr: "+strings.TrimSpace(t.Address))
-				resultArray = append(resultArray, "block: "+strings.TrimSpace(t.Block))
-				resultArray = append(resultArray, "type: "+strings.TrimSpace(t.Type))
-				resultArray = append(resultArray, "bit: "+strings.TrimSpace(t.Bit))
-				resultArray = append(resultArray, "invert_bit: "+strings.TrimSpace(t.InvertBit))
-
-				y := 20
-				for _, str := range resultArray {
-					pdf.SetXY(10, float64(y))
-					err := pdf.Text(str)
-					if err != nil {
-						return fmt.Errorf("can't write string to PDF: %w", err)
-					}
-					y += 20
-				}
-			}
+		file, err := os.Create(resultFile)
+		if err != nil {
+			return fmt.Errorf("failed to create %s file: %w", format, err)
 		}
 
-		dir := filepath.Dir(strings.TrimPrefix(filePath, s.dirFrom))
-		if err := os.MkdirAll(s.dirTo+dir, 0755); err != nil {
-			return fmt.Errorf("failed to create directory: %w", err)
+		err = s.Render(ctx, file, format, labels, rows, key)
+		file.Close()
+		if err != nil {
+			return err
 		}
+	}
+	return errors.Join(badKeys...)
+}
 
-		resultFile := s.dirTo + dir + "/" + guid + ".pdf"
-		err = pdf.WritePdf(resultFile)
-		if err != nil {
-			return fmt.Errorf("failed to write result")
+func lines(labels []string, row shema.Record) []string {
+	var result []string
+	for i, v := range row.Values {
+		result = append(result, labels[i]+": "+strings.TrimSpace(v))
+	}
+	return result
+}
+
+func (s *Writer) renderPDF(w io.Writer, labels []string, rows []shema.Record, key string) error {
+	pdf := gopdf.GoPdf{}
+	pdf.Start(gopdf.Config{PageSize: *gopdf.PageSizeA4})
+	pdf.AddPage()
+
+	defer pdf.Close()
+
+	err := pdf.AddTTFFont("LiberationSerif-Regular", "resources/LiberationSerif-Regular.ttf")
+	if err != nil {
+		return fmt.Errorf("can't add font: %w", err)
+	}
+
+	err = pdf.SetFont("LiberationSerif-Regular", "", 14)
+	if err != nil {
+		return fmt.Errorf("can't set font: %w", err)
+	}
+
+	for _, row := range rows {
+		if key == row.Key {
+			pdf.AddPage()
+
+			y := 20
+			for _, str := range lines(labels, row) {
+				pdf.SetXY(10, float64(y))
+				err := pdf.Text(str)
+				if err != nil {
+					return fmt.Errorf("can't write string to PDF: %w", err)
+				}
+				y += 20
+			}
 		}
+	}
 
+	err = pdf.Write(w)
+	if err != nil {
+		return fmt.Errorf("failed to write result")
 	}
 	return nil
 }
@@ -99,14 +222,14 @@ func add(a, b int) int {
 	return a + b
 }
 
-// WriteSVG write svg files
-func (s *Writer) WriteSVG(tsv []shema.Tsv, unitGuid []string, filePath string) error {
+func (s *Writer) renderSVG(w io.Writer, labels []string, rows []shema.Record, key string) error {
 	svgTemplate, err := os.ReadFile("maket.svg")
 	if err != nil {
 		return fmt.Errorf("failed to read SVG template file: %w", err)
 	}
 
-	tmpl := template.New("svg").Funcs(template.FuncMap{
+	// values come from files and are escaped as in html report
+	tmpl := htmltemplate.New("svg").Funcs(htmltemplate.FuncMap{
 		"mul": mul,
 		"add": add,
 	})
@@ -118,55 +241,100 @@ func (s *Writer) WriteSVG(tsv []shema.Tsv, unitGuid []string, filePath string) e
 	lineHeight := 30
 	blockSpacing := 60
 
-	for _, guid := range unitGuid {
-
-		dir := filepath.Dir(strings.TrimPrefix(filePath, s.dirFrom))
-		if err := os.MkdirAll(s.dirTo+dir, 0755); err != nil {
-			return fmt.Errorf("failed to create directory: %w", err)
+	var result []string
+	for i, row := range rows {
+		if key == row.Key {
+			if i != 0 {
+				result = append(result, "")
+			}
+			result = append(result, lines(labels, row)...)
 		}
+	}
 
-		resultFile := s.dirTo + dir + "/" + guid + ".svg"
-		file, err := os.Create(resultFile)
+	data := SVGData{
+		Height: (len(result) * lineHeight) + (len(rows) * blockSpacing),
+		Lines:  result,
+	}
+
+	err = tmpl.Execute(w, data)
+	if err != nil {
+		return fmt.Errorf("failed to execute SVG template: %w", err)
+	}
+	return nil
+}
+
+func (s *Writer) renderXLSX(w io.Writer, labels []string, rows []shema.Record, key string) error {
+	xlsx := excelize.NewFile()
+	defer xlsx.Close()
+
+	sheet := xlsx.GetSheetName(0)
+	header := make([]interface{}, len(labels))
+	for i, l := range labels {
+		header[i] = l
+	}
+	err := xlsx.SetSheetRow(sheet, "A1", &header)
+	if err != nil {
+		return fmt.Errorf("failed to write xlsx header: %w", err)
+	}
+
+	row := 2
+	for _, r := range rows {
+		if key != r.Key {
+			continue
+		}
+		var cells []interface{}
+		for _, v := range r.Values {
+			cells = append(cells, strings.TrimSpace(v))
+		}
+		err = xlsx.SetSheetRow(sheet, fmt.Sprintf("A%d", row), &cells)
 		if err != nil {
-			return fmt.Errorf("failed to create SVG file: %w", err)
+			return fmt.Errorf("failed to write xlsx row: %w", err)
 		}
+		row++
+	}
 
-		defer file.Close()
+	err = xlsx.Write(w)
+	if err != nil {
+		return fmt.Errorf("failed to write xlsx: %w", err)
+	}
+	return nil
+}
 
-		var lines []string
-		for i, t := range tsv {
-			if guid == t.UnitGUID {
-				if i != 0 {
-					lines = append(lines, "")
-				}
+var htmlTemplate = htmltemplate.Must(htmltemplate.New("html").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>{{.Key}}</title></head>
+<body>
+<h1>{{.Key}}</h1>
+<table border="1">
+<tr>{{range .Labels}}<th>{{.}}</th>{{end}}</tr>
+{{range .Rows}}<tr>{{range .}}<td>{{.}}</td>{{end}}</tr>
+{{end}}</table>
+</body>
+</html>
+`))
 
-				lines = append(lines, "n: "+strings.TrimSpace(t.Number),
-					"mqtt: "+strings.TrimSpace(t.MQTT),
-					"invid: "+strings.TrimSpace(t.InventoryID),
-					"unit_guid: "+strings.TrimSpace(t.UnitGUID),
-					"msg_id: "+strings.TrimSpace(t.MessageID),
-					"text: "+strings.TrimSpace(t.MessageText),
-					"context: "+strings.TrimSpace(t.Context),
-					"class: "+strings.TrimSpace(t.MessageClass),
-					"level: "+strings.TrimSpace(t.Level),
-					"area: "+strings.TrimSpace(t.Area),
-					"addr: "+strings.TrimSpace(t.Address),
-					"block: "+strings.TrimSpace(t.Block),
-					"type: "+strings.TrimSpace(t.Type),
-					"bit: "+strings.TrimSpace(t.Bit),
-					"invert_bit: "+strings.TrimSpace(t.InvertBit))
-			}
-		}
+type HTMLData struct {
+	Key    string
+	Labels []string
+	Rows   [][]string
+}
 
-		data := SVGData{
-			Height: (len(lines) * lineHeight) + (len(tsv) * blockSpacing),
-			Lines:  lines,
+func (s *Writer) renderHTML(w io.Writer, labels []string, rows []shema.Record, key string) error {
+	data := HTMLData{Key: key, Labels: labels}
+	for _, r := range rows {
+		if key != r.Key {
+			continue
 		}
-
-		err = tmpl.Execute(file, data)
-		if err != nil {
-			return fmt.Errorf("failed to execute SVG template: %w", err)
+		var row []string
+		for _, v := range r.Values {
+			row = append(row, strings.TrimSpace(v))
 		}
+		data.Rows = append(data.Rows, row)
+	}
+
+	err := htmlTemplate.Execute(w, data)
+	if err != nil {
+		return fmt.Errorf("failed to execute HTML template: %w", err)
 	}
 	return nil
 }
@@ -0,0 +1,125 @@
+package workers
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"goTSVParser/config"
+	"goTSVParser/internal/shema"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReportFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		want    string
+		wantErr bool
+	}{
+		{name: "OK#1", key: "01749246-9617-585e-9e19-157ccad61ee2", want: "out/01749246-9617-585e-9e19-157ccad61ee2.pdf"},
+		{name: "OK#2", key: "device 1.a", want: "out/device 1.a.pdf"},
+		{name: "BAD#1", key: "", wantErr: true},
+		{name: "BAD#2", key: "../../etc/x", wantErr: true},
+		{name: "BAD#3", key: "..", wantErr: true},
+		{name: "BAD#4", key: "a/b", wantErr: true},
+		{name: "BAD#5", key: `a\b`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := reportFile("out", tt.key, FormatPDF)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got %v, want err %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriter_WriteBadKey(t *testing.T) {
+	dir := t.TempDir()
+	from, to := filepath.Join(dir, "from"), filepath.Join(dir, "to")
+	w := NewWriter(config.Config{DirectoryFrom: from, DirectoryTo: to})
+
+	err := w.WritePDF(context.Background(), nil, nil, []string{"../../x"}, filepath.Join(from, "a.tsv"))
+	if !errors.Is(err, errBadKey) {
+		t.Fatalf("got %v, want %v", err, errBadKey)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "x.pdf")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("report is written outside of dir_to: %v", err)
+	}
+}
+
+func TestWriter_OutputDir(t *testing.T) {
+	w := NewWriter(config.Config{DirectoryFrom: "from", DirectoryTo: "to"})
+	// file is found before reload and written after it
+	found := []string{"from/a.tsv", "from/units.zip!/2024/a.tsv"}
+	w.Reload(config.Config{DirectoryFrom: "new", DirectoryTo: "out"})
+
+	tests := []struct {
+		name string
+		file string
+		want string
+	}{
+		{name: "OK#1", file: "new/dir/a.tsv", want: "out/dir"},
+		{name: "OK#2", file: found[0], want: "out"},
+		{name: "OK#3", file: found[1], want: "out/units.zip/2024"},
+		{name: "OK#4", file: "newer/a.tsv", want: "out/newer"},
+		{name: "OK#5", file: "../a.tsv", want: "out"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := w.OutputDir(tt.file); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriter_RenderSVGEscape(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// template is read from working directory of service
+	if err := os.Chdir("../.."); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	value := `<script>alert("x")</script> & more`
+	var buf bytes.Buffer
+	rows := []shema.Record{{Key: "dev-1", Values: []string{"dev-1", value}}}
+	if err := NewWriter(config.Config{}).Render(context.Background(), &buf, FormatSVG, []string{"device", "message"}, rows, "dev-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	decoder := xml.NewDecoder(&buf)
+	var text strings.Builder
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("svg is not well-formed: %v", err)
+		}
+		switch token := token.(type) {
+		case xml.StartElement:
+			if token.Name.Local == "script" {
+				t.Fatal("value is rendered as script element")
+			}
+		case xml.CharData:
+			text.Write(token)
+		}
+	}
+	if !strings.Contains(text.String(), "message: "+value) {
+		t.Errorf("value is not kept as text: %q", text.String())
+	}
+}
@@ -0,0 +1,24 @@
+CREATE TABLE jobs (
+                      id          VARCHAR(32) PRIMARY KEY,
+                      file        VARCHAR(255) NOT NULL UNIQUE,
+                      status      VARCHAR(16) NOT NULL,
+                      rows        INTEGER NOT NULL DEFAULT 0,
+                      unit_guids  TEXT[] NOT NULL DEFAULT '{}',
+                      error       TEXT,
+                      created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+                      started_at  TIMESTAMPTZ,
+                      stored_at   TIMESTAMPTZ,
+                      finished_at TIMESTAMPTZ,
+                      updated_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX jobs_status_idx ON jobs (status);
+CREATE INDEX jobs_created_at_idx ON jobs (created_at);
+
+INSERT INTO jobs (id, file, status)
+SELECT md5(name), name, 'stored' FROM checkedFiles
+ON CONFLICT DO NOTHING;
+
+INSERT INTO jobs (id, file, status, error)
+SELECT md5(name), name, 'failed', error FROM checkedFilesWithErr
+ON CONFLICT DO NOTHING;
@@ -0,0 +1 @@
+DROP INDEX occurrence_unitguid_id_idx;
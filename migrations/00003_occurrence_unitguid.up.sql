@@ -0,0 +1 @@
+CREATE INDEX occurrence_unitguid_id_idx ON occurrence (unitguid, id);
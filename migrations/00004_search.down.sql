@@ -0,0 +1,7 @@
+DROP INDEX occurrence_messagetext_fts_idx;
+DROP INDEX occurrence_level_idx;
+DROP INDEX occurrence_messageid_prefix_idx;
+DROP INDEX occurrence_mqtt_idx;
+DROP INDEX occurrence_area_idx;
+DROP INDEX occurrence_messageclass_idx;
+DROP INDEX occurrence_inventoryid_idx;
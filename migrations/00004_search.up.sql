@@ -0,0 +1,7 @@
+CREATE INDEX occurrence_inventoryid_idx ON occurrence (inventoryid);
+CREATE INDEX occurrence_messageclass_idx ON occurrence (messageclass);
+CREATE INDEX occurrence_area_idx ON occurrence (area);
+CREATE INDEX occurrence_mqtt_idx ON occurrence (mqtt);
+CREATE INDEX occurrence_messageid_prefix_idx ON occurrence (messageid varchar_pattern_ops);
+CREATE INDEX occurrence_level_idx ON occurrence ((CASE WHEN level ~ '^-?[0-9]{1,9}$' THEN level::integer END));
+CREATE INDEX occurrence_messagetext_fts_idx ON occurrence USING GIN (to_tsvector('russian', messagetext));
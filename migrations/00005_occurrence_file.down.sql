@@ -0,0 +1,3 @@
+DROP INDEX occurrence_file_idx;
+
+ALTER TABLE occurrence DROP COLUMN file;
@@ -0,0 +1,3 @@
+ALTER TABLE occurrence ADD COLUMN file VARCHAR(255);
+
+CREATE INDEX occurrence_file_idx ON occurrence (file);
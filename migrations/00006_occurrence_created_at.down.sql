@@ -0,0 +1 @@
+ALTER TABLE occurrence DROP COLUMN created_at;
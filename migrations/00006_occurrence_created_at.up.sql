@@ -0,0 +1 @@
+ALTER TABLE occurrence ADD COLUMN created_at TIMESTAMPTZ NOT NULL DEFAULT now();
@@ -0,0 +1 @@
+ALTER TABLE jobs ADD COLUMN dropped JSONB NOT NULL DEFAULT '{}';
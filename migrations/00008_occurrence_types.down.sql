@@ -0,0 +1,16 @@
+DROP INDEX IF EXISTS occurrence_level_idx;
+
+ALTER TABLE occurrence DROP CONSTRAINT IF EXISTS occurrence_unitguid_check;
+
+ALTER TABLE occurrence
+    ALTER COLUMN number TYPE VARCHAR(255) USING COALESCE(number::text, ''),
+    ALTER COLUMN level TYPE VARCHAR(255) USING COALESCE(level::text, ''),
+    ALTER COLUMN bit TYPE VARCHAR(255) USING COALESCE(bit::text, ''),
+    ALTER COLUMN invertbit TYPE VARCHAR(255) USING COALESCE(invertbit::text, '');
+
+UPDATE occurrence o SET number = b.number, level = b.level, bit = b.bit, invertbit = b.invertbit
+FROM occurrence_types_backup b WHERE o.id = b.id;
+
+DROP TABLE occurrence_types_backup;
+
+CREATE INDEX occurrence_level_idx ON occurrence ((CASE WHEN level ~ '^-?[0-9]{1,9}$' THEN level::integer END));
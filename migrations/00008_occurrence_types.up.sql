@@ -0,0 +1,24 @@
+DROP INDEX IF EXISTS occurrence_level_idx;
+
+-- values which are lost or changed by conversion are kept, down migration puts them back
+CREATE TABLE occurrence_types_backup AS
+SELECT id, number, level, bit, invertbit FROM occurrence
+WHERE (number <> '' AND number !~ '^(0|-?[1-9][0-9]{0,8})$')
+   OR (level <> '' AND level !~ '^(0|-?[1-9][0-9]{0,8})$')
+   OR (bit <> '' AND bit !~ '^(0|-?[1-9][0-9]{0,8})$')
+   OR invertbit NOT IN ('', 'true', 'false');
+
+ALTER TABLE occurrence
+    ALTER COLUMN number TYPE INTEGER USING CASE WHEN number ~ '^\s*-?[0-9]{1,9}\s*$' THEN trim(number)::integer END,
+    ALTER COLUMN level TYPE INTEGER USING CASE WHEN level ~ '^\s*-?[0-9]{1,9}\s*$' THEN trim(level)::integer END,
+    ALTER COLUMN bit TYPE INTEGER USING CASE WHEN bit ~ '^\s*-?[0-9]{1,9}\s*$' THEN trim(bit)::integer END,
+    ALTER COLUMN invertbit TYPE BOOLEAN USING CASE
+        WHEN lower(trim(invertbit)) IN ('1', 't', 'true') THEN true
+        WHEN lower(trim(invertbit)) IN ('0', 'f', 'false') THEN false
+    END;
+
+-- rows saved before validation are not checked
+ALTER TABLE occurrence ADD CONSTRAINT occurrence_unitguid_check
+    CHECK (unitguid ~ '^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$') NOT VALID;
+
+CREATE INDEX occurrence_level_idx ON occurrence (level);
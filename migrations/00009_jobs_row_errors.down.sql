@@ -0,0 +1,2 @@
+ALTER TABLE jobs DROP COLUMN row_errors;
+ALTER TABLE jobs DROP COLUMN invalid;
@@ -0,0 +1,2 @@
+ALTER TABLE jobs ADD COLUMN invalid INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE jobs ADD COLUMN row_errors JSONB NOT NULL DEFAULT '[]';
@@ -0,0 +1,4 @@
+ALTER TABLE checkedFilesWithErr ALTER COLUMN name TYPE VARCHAR(255);
+ALTER TABLE checkedFiles ALTER COLUMN name TYPE VARCHAR(255);
+ALTER TABLE occurrence ALTER COLUMN file TYPE VARCHAR(255);
+ALTER TABLE jobs ALTER COLUMN file TYPE VARCHAR(255);
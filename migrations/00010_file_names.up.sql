@@ -0,0 +1,5 @@
+-- members of archives are named archive!/member and may be longer
+ALTER TABLE jobs ALTER COLUMN file TYPE TEXT;
+ALTER TABLE occurrence ALTER COLUMN file TYPE TEXT;
+ALTER TABLE checkedFiles ALTER COLUMN name TYPE TEXT;
+ALTER TABLE checkedFilesWithErr ALTER COLUMN name TYPE TEXT;